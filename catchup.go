@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Decides what happens to the reminders that became due while the bot was offline.
+// Set with the GOPNIK_CATCHUP_POLICY environment variable.
+type catchUpPolicy string
+
+const (
+	// Every missed reminder is sent separately, just marked as late.
+	catchUpAll catchUpPolicy = "all"
+	// The missed reminders are sent once per user in a single message.
+	catchUpOnce catchUpPolicy = "once"
+	// The missed reminders aren't sent, the user only gets told which ones were skipped.
+	catchUpSkip catchUpPolicy = "skip"
+)
+
+// Reminders delivered later than this are considered missed.
+const lateTolerance = time.Minute
+
+// Discord rejects messages longer than that.
+const maxMessageLength = 2000
+
+func parseCatchUpPolicy(policy string) (catchUpPolicy, error) {
+	switch catchUpPolicy(policy) {
+	case catchUpAll, catchUpOnce, catchUpSkip:
+		return catchUpPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown catch-up policy %q, has to be either %q, %q or %q", policy, catchUpAll, catchUpOnce, catchUpSkip)
+	}
+}
+
+// Formats the delay as e.g. "2d 3h 12m".
+func formatLateness(lateness time.Duration) string {
+	lateness = lateness.Round(time.Minute)
+
+	days := lateness / (24 * time.Hour)
+	hours := (lateness % (24 * time.Hour)) / time.Hour
+	minutes := (lateness % time.Hour) / time.Minute
+
+	parts := []string{}
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Collects the reminders missed during a downtime, so that each user gets a single summary
+// instead of a burst of messages.
+type missedReminders struct {
+	recipients  []missedRecipient
+	preferences map[missedRecipient]deliveryPreference
+	lines       map[missedRecipient][]string
+}
+
+// The users get a separate summary for every channel their reminders would be sent in.
+type missedRecipient struct {
+	messenger        Messenger
+	who              string
+	defaultChannelId string
+}
+
+func (m *missedReminders) add(messenger Messenger, who string, preference deliveryPreference, defaultChannelId string, toRemind string, dueTime time.Time, location *time.Location, lateness time.Duration) {
+	if m.lines == nil {
+		m.lines = make(map[missedRecipient][]string)
+		m.preferences = make(map[missedRecipient]deliveryPreference)
+	}
+
+	recipient := missedRecipient{messenger: messenger, who: who, defaultChannelId: defaultChannelId}
+	if _, ok := m.lines[recipient]; !ok {
+		m.recipients = append(m.recipients, recipient)
+		m.preferences[recipient] = preference
+	}
+
+	m.lines[recipient] = append(m.lines[recipient], loadLanguage(who).sprintf("- %s, due on %s (%s ago)", messenger.escapeMentions(toRemind), messenger.timestamp(dueTime, location, loadClockPreference(who)), formatLateness(lateness)))
+}
+
+func (m *missedReminders) send(policy catchUpPolicy) {
+	for _, recipient := range m.recipients {
+		messenger := recipient.messenger
+		lang := loadLanguage(recipient.who)
+		var header string
+		if policy == catchUpSkip {
+			header = lang.sprintf("%s, I was offline for a while and skipped these reminders of yours:", messenger.mention(recipient.who))
+		} else {
+			header = lang.sprintf("%s, I was offline for a while, so I'm late with reminding you:", messenger.mention(recipient.who))
+		}
+
+		// Split the summary into as many messages as needed, without breaking the lines.
+		var message strings.Builder
+		message.WriteString(header)
+		for _, line := range m.lines[recipient] {
+			// Leave some room for the note added when the DM can't be sent.
+			if message.Len()+len(line)+1 > maxMessageLength-len(lang.translate(dmFallbackNote)) {
+				m.sendSummary(recipient, message.String())
+				message.Reset()
+			} else {
+				message.WriteString("\n")
+			}
+			message.WriteString(line)
+		}
+
+		m.sendSummary(recipient, message.String())
+	}
+}
+
+func (m *missedReminders) sendSummary(recipient missedRecipient, content string) {
+	// The summary covers several reminders, so it's never sent in their original channels.
+	preference := m.preferences[recipient]
+	if preference == deliverHere {
+		preference = deliverDefault
+	}
+
+	err := sendToUser(recipient.messenger, recipient.who, preference, "", recipient.defaultChannelId, outgoingMessage{content: content, mentions: []string{recipient.who}})
+	if err != nil {
+		log.Println("Error sending the missed reminders:", err)
+	}
+}
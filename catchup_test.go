@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCatchUpPolicy(t *testing.T) {
+	for _, policy := range []string{"all", "once", "skip"} {
+		parsed, err := parseCatchUpPolicy(policy)
+		if err != nil || string(parsed) != policy {
+			t.Errorf("expected the %q policy, got %q (%v)", policy, parsed, err)
+		}
+	}
+
+	for _, policy := range []string{"", "ALL", "none", "once "} {
+		if parsed, err := parseCatchUpPolicy(policy); err == nil {
+			t.Errorf("expected %q to be rejected, got %q", policy, parsed)
+		}
+	}
+}
+
+func TestFormatLateness(t *testing.T) {
+	tests := []struct {
+		lateness time.Duration
+		expected string
+	}{
+		{0, "0m"},
+		{29 * time.Second, "0m"},
+		{90 * time.Second, "2m"},
+		{time.Hour, "1h"},
+		{time.Hour + 5*time.Minute, "1h 5m"},
+		{24 * time.Hour, "1d"},
+		{2*24*time.Hour + 3*time.Hour + 12*time.Minute, "2d 3h 12m"},
+		{3*24*time.Hour + 59*time.Second, "3d 1m"},
+		{40 * 24 * time.Hour, "40d"},
+	}
+
+	for _, test := range tests {
+		if formatted := formatLateness(test.lateness); formatted != test.expected {
+			t.Errorf("formatLateness(%v) = %q, expected %q", test.lateness, formatted, test.expected)
+		}
+	}
+}
+
+func TestMissedRemindersSendsASummaryPerChannel(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	dueTime := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	missed := missedReminders{}
+	missed.add(messenger, "aurora", deliverDefault, "general", "to call mom", dueTime, time.UTC, 2*time.Hour)
+	missed.add(messenger, "bruno", deliverHere, "general", "to ping @everyone", dueTime, time.UTC, 3*time.Minute)
+	missed.add(messenger, "aurora", deliverDefault, "other", "to stretch", dueTime, time.UTC, 25*time.Hour)
+	missed.add(messenger, "aurora", deliverDefault, "general", "to buy milk", dueTime, time.UTC, 90*time.Minute)
+	missed.send(catchUpOnce)
+
+	_, sent, dms := messenger.take()
+	if len(dms) > 0 {
+		t.Errorf("expected no DMs, got %+v", dms)
+	}
+
+	expected := []sentMessage{
+		{to: "general", message: outgoingMessage{content: "<@aurora>, I was offline for a while, so I'm late with reminding you:\n" +
+			"- to call mom, due on 14.10.2026 09:00 AM UTC (2h ago)\n" +
+			"- to buy milk, due on 14.10.2026 09:00 AM UTC (1h 30m ago)"}},
+		// Never in the original channel, since the summary covers several reminders.
+		{to: "general", message: outgoingMessage{content: "<@bruno>, I was offline for a while, so I'm late with reminding you:\n" +
+			"- to ping @\u200beveryone, due on 14.10.2026 09:00 AM UTC (3m ago)"}},
+		{to: "other", message: outgoingMessage{content: "<@aurora>, I was offline for a while, so I'm late with reminding you:\n" +
+			"- to stretch, due on 14.10.2026 09:00 AM UTC (1d 1h ago)"}},
+	}
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d summaries, got %+v", len(expected), sent)
+	}
+	for i, message := range sent {
+		if message.to != expected[i].to || message.message.content != expected[i].message.content {
+			t.Errorf("expected %q in %q, got %q in %q", expected[i].message.content, expected[i].to, message.message.content, message.to)
+		}
+	}
+}
+
+func TestMissedRemindersSplitsTheLongSummaries(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	// With the DMs closed, the summary falls back to the channel with the note added.
+	messenger.closedDms["aurora"] = true
+	dueTime := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	missed := missedReminders{}
+	lines := []string{}
+	for i := range 60 {
+		toRemind := fmt.Sprintf("to water the plant number %d in the ćwierćwiecze greenhouse", i)
+		missed.add(messenger, "aurora", deliverDm, "channel", toRemind, dueTime, time.UTC, time.Hour)
+		lines = append(lines, fmt.Sprintf("- %s, due on 14.10.2026 09:00 AM UTC (1h ago)", toRemind))
+	}
+	missed.send(catchUpSkip)
+
+	_, sent, _ := messenger.take()
+	if len(sent) < 2 {
+		t.Fatalf("expected the summary to be split, got %d messages", len(sent))
+	}
+
+	received := []string{}
+	for i, message := range sent {
+		content := message.message.content
+		if len(content) > maxMessageLength {
+			t.Errorf("expected at most %d characters in the message %d, got %d", maxMessageLength, i, len(content))
+		}
+
+		content, ok := strings.CutSuffix(content, dmFallbackNote)
+		if !ok {
+			t.Errorf("expected the note in the message %d, got %q", i, content)
+		}
+		if i == 0 {
+			header := "<@aurora>, I was offline for a while and skipped these reminders of yours:\n"
+			if content, ok = strings.CutPrefix(content, header); !ok {
+				t.Fatalf("expected the message to start with %q, got %q", header, content)
+			}
+		}
+		received = append(received, strings.Split(content, "\n")...)
+	}
+
+	// Every line comes whole and in order.
+	if strings.Join(received, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("expected the lines\n%s\ngot\n%s", strings.Join(lines, "\n"), strings.Join(received, "\n"))
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Decides how the times of day are shown to the user. Set with `!clockpreference`.
+type clockPreference string
+
+const (
+	clock12h clockPreference = "12h"
+	clock24h clockPreference = "24h"
+)
+
+// Formats the time of day, e.g. "05:30 PM" or "17:30".
+func (p clockPreference) format(t time.Time) string {
+	if p == clock24h {
+		return t.Format("15:04")
+	}
+
+	return t.Format("03:04 PM")
+}
+
+// Returns the user's preference, falling back to the 12-hour clock if it can't be read.
+func loadClockPreference(who string) clockPreference {
+	preference, err := store.clockPreference(who)
+	if err != nil {
+		log.Println("Error querying the clock preference:", err)
+		return clock12h
+	}
+
+	return preference
+}
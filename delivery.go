@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+)
+
+// Decides where the user's reminders are sent. Set with `!deliverypreference`.
+type deliveryPreference string
+
+const (
+	// The reminders are sent in the DMs.
+	deliverDm deliveryPreference = "dm"
+	// The reminders are sent in the channel they were set in.
+	deliverHere deliveryPreference = "here"
+	// The reminders are sent in the guild's reminders channel.
+	deliverDefault deliveryPreference = "default"
+)
+
+// Appended to the reminders that couldn't be sent in the DMs.
+const dmFallbackNote = "\n*I couldn't DM you, so I'm reminding you here. Make sure you allow DMs from this server or change `!deliverypreference`.*"
+
+// Sends the message to the user according to their preference, originChannelId being the channel the reminder
+// was set in. If the preferred channel doesn't work out, e.g. because the user has the DMs closed or the bot
+// can't see the original channel anymore, the message is sent in the default channel instead.
+func sendToUser(messenger Messenger, who string, preference deliveryPreference, originChannelId string, defaultChannelId string, message outgoingMessage) error {
+	switch preference {
+	case deliverDm:
+		err := messenger.sendDm(who, message)
+		if err == nil {
+			return nil
+		}
+		log.Println("Error sending the DM, falling back to the default channel:", err)
+
+		message.content += loadLanguage(who).translate(dmFallbackNote)
+		return messenger.sendToChannel(defaultChannelId, message)
+	case deliverHere:
+		if len(originChannelId) > 0 && originChannelId != defaultChannelId {
+			err := messenger.sendToChannel(originChannelId, message)
+			if err == nil {
+				return nil
+			}
+			log.Println("Error sending the message in the original channel, falling back to the default channel:", err)
+
+			message.replyTo = nil
+		}
+	}
+
+	return messenger.sendToChannel(defaultChannelId, message)
+}
+
+// Sends the reminder to the user, or to the role in their name when the role isn't empty. Nobody else gets
+// notified, e.g. the one who set the reminder for them.
+func sendReminder(messenger Messenger, id int64, who string, role string, preference deliveryPreference, defaultChannelId string, content string, about messageRef) error {
+	message := outgoingMessage{content: content, reminderId: id, mentions: []string{who}}
+	if len(role) > 0 {
+		message.mentions = nil
+		message.mentionedRole = role
+	}
+
+	// Reply to the message the reminder is about, if it's sent in the same channel.
+	target := defaultChannelId
+	switch {
+	case preference == deliverDm:
+		target = ""
+	case preference == deliverHere && len(about.channelId) > 0:
+		target = about.channelId
+	}
+	if len(about.messageId) > 0 && about.channelId == target {
+		message.replyTo = &about
+	}
+
+	return sendToUser(messenger, who, preference, about.channelId, defaultChannelId, message)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Talks to Discord. For the commands, it also keeps the event they came from, to reply to it.
+type discordMessenger struct {
+	session *discordgo.Session
+	// Set when the command comes from a message with the `!` prefix.
+	message *discordgo.MessageCreate
+	// Set when the command comes from an application command, e.g. `/remindme`.
+	interaction *discordgo.Interaction
+	responded   bool
+}
+
+func (m *discordMessenger) platform() string {
+	return platformDiscord
+}
+
+func (m *discordMessenger) reply(cmd *incomingCommand, msg string) {
+	if m.interaction == nil {
+		// The replies can echo someone else's mentions, e.g. from the reminder's text. Only the author gets notified.
+		m.session.ChannelMessageSendComplex(m.message.ChannelID, &discordgo.MessageSend{
+			Content:         msg,
+			Reference:       m.message.Reference(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{RepliedUser: true},
+		})
+		return
+	}
+
+	// An interaction can only be responded to once, the subsequent replies have to be follow-ups.
+	if !m.responded {
+		err := m.session.InteractionRespond(m.interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+		})
+		if err != nil {
+			log.Println("Error responding to the interaction:", err)
+		}
+		m.responded = true
+		return
+	}
+
+	_, err := m.session.FollowupMessageCreate(m.interaction, false, &discordgo.WebhookParams{Content: msg, Flags: discordgo.MessageFlagsEphemeral})
+	if err != nil {
+		log.Println("Error sending a follow-up message:", err)
+	}
+}
+
+func (m *discordMessenger) sendToChannel(channelId string, message outgoingMessage) error {
+	// Only the recipients get notified, not whoever else the content mentions.
+	allowedMentions := discordgo.MessageAllowedMentions{Users: message.mentions}
+	switch message.mentionedRole {
+	case "":
+	case roleHere:
+		allowedMentions.Parse = []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeEveryone}
+	default:
+		allowedMentions.Roles = []string{message.mentionedRole}
+	}
+
+	send := discordgo.MessageSend{Content: message.content, AllowedMentions: &allowedMentions}
+	if message.reminderId != 0 {
+		send.Components = reminderButtons(message.reminderId)
+	}
+	if message.replyTo != nil {
+		// The message might have been deleted in the meantime, the reminder is still worth sending.
+		failIfNotExists := false
+		send.Reference = &discordgo.MessageReference{
+			GuildID:         message.replyTo.guildId,
+			ChannelID:       message.replyTo.channelId,
+			MessageID:       message.replyTo.messageId,
+			FailIfNotExists: &failIfNotExists,
+		}
+	}
+
+	_, err := m.session.ChannelMessageSendComplex(channelId, &send)
+	return err
+}
+
+func (m *discordMessenger) sendDm(who string, message outgoingMessage) error {
+	channel, err := m.session.UserChannelCreate(who)
+	if err != nil {
+		return err
+	}
+
+	return m.sendToChannel(channel.ID, message)
+}
+
+func (m *discordMessenger) mention(who string) string {
+	return fmt.Sprintf("<@%s>", who)
+}
+
+func (m *discordMessenger) mentionRole(role string) string {
+	if role == roleHere {
+		return "@here"
+	}
+
+	return fmt.Sprintf("<@&%s>", role)
+}
+
+// A zero-width space after the `@` keeps the text as it is, without pinging anyone.
+var discordEveryoneRegex = regexp.MustCompile(`@(everyone|here)`)
+
+func (m *discordMessenger) escapeMentions(text string) string {
+	return discordEveryoneRegex.ReplaceAllString(text, "@\u200b$1")
+}
+
+var (
+	discordUserMentionRegex = regexp.MustCompile(`^<@!?(\d+)>$`)
+	discordRoleMentionRegex = regexp.MustCompile(`^<@&(\d+)>$`)
+)
+
+func (m *discordMessenger) parseMention(mention string) (string, bool, bool) {
+	if matches := discordUserMentionRegex.FindStringSubmatch(mention); matches != nil {
+		return matches[1], false, true
+	}
+
+	if matches := discordRoleMentionRegex.FindStringSubmatch(mention); matches != nil {
+		return matches[1], true, true
+	}
+
+	return "", false, false
+}
+
+func (m *discordMessenger) timestamp(t time.Time, location *time.Location, clock clockPreference) string {
+	// The app shows the timestamp according to the viewer's language settings, the clock can't be chosen for it.
+	local := t.In(location)
+	return fmt.Sprintf("<t:%d:f> (%s %s %s)", t.Unix(), local.Format("02.01.2006"), clock.format(local), local.Format("MST"))
+}
+
+func (m *discordMessenger) messageLink(message messageRef) string {
+	guildId := message.guildId
+	// Messages in the DMs don't belong to any guild.
+	if len(guildId) == 0 {
+		guildId = "@me"
+	}
+
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildId, message.channelId, message.messageId)
+}
+
+func (m *discordMessenger) canManageGuild(cmd *incomingCommand) bool {
+	// The interactions already come with the member's permissions.
+	if m.interaction != nil {
+		return m.interaction.Member != nil && m.interaction.Member.Permissions&discordgo.PermissionManageServer != 0
+	}
+
+	_, channelId := cmd.origin()
+	permissions, err := m.session.UserChannelPermissions(cmd.author, channelId)
+	if err != nil {
+		log.Println("Error checking the permissions:", err)
+		return false
+	}
+
+	return permissions&discordgo.PermissionManageServer != 0
+}
+
+func (m *discordMessenger) channelGuild(channelId string) (string, error) {
+	channel, err := m.session.Channel(channelId)
+	if err != nil {
+		return "", err
+	}
+
+	return channel.GuildID, nil
+}
+
+// The member is only set for the commands used in the guilds.
+func memberRoles(member *discordgo.Member) []string {
+	if member == nil {
+		return nil
+	}
+
+	return member.Roles
+}
+
+func messageCreate(session *discordgo.Session, message *discordgo.MessageCreate) {
+	// Ignore the bot's own messages.
+	if message.Author.ID == session.State.User.ID {
+		return
+	}
+
+	// Ignore other bots' shenanigans.
+	if message.Author.Bot {
+		return
+	}
+
+	// The commands are matched with the default prefix, whatever the guild uses.
+	content, ok := strings.CutPrefix(message.Content, loadGuildSettings(message.GuildID).prefix)
+	if !ok {
+		return
+	}
+
+	cmd := incomingCommand{
+		messenger: &discordMessenger{session: session, message: message},
+		author:    message.Author.ID,
+		guildId:   message.GuildID,
+		channelId: message.ChannelID,
+		roles:     memberRoles(message.Member),
+	}
+	handleCommand(&cmd, defaultPrefix+content)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Answers the Discord API calls of the session, keeping the messages it was asked to send.
+type fakeDiscord struct {
+	messages []discordgo.MessageSend
+}
+
+func (fake *fakeDiscord) RoundTrip(request *http.Request) (*http.Response, error) {
+	var message discordgo.MessageSend
+	if err := json.NewDecoder(request.Body).Decode(&message); err == nil {
+		fake.messages = append(fake.messages, message)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"1","channel_id":"channel"}`)),
+		Request:    request,
+	}, nil
+}
+
+func newFakeDiscordMessenger(t *testing.T) (*discordMessenger, *fakeDiscord) {
+	t.Helper()
+
+	session, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeDiscord{}
+	session.Client = &http.Client{Transport: fake}
+	return &discordMessenger{session: session}, fake
+}
+
+func TestDiscordAllowedMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		message outgoingMessage
+		users   []string
+		roles   []string
+		parse   []discordgo.AllowedMentionType
+	}{
+		{"user", outgoingMessage{content: "<@1>, reminding you to ping <@2> and <@&3>.", mentions: []string{"1"}}, []string{"1"}, nil, nil},
+		{"role", outgoingMessage{content: "<@&3>, <@1> asked me to remind you to vote.", mentionedRole: "3"}, nil, []string{"3"}, nil},
+		{"here", outgoingMessage{content: "@here, <@1> asked me to remind you to vote.", mentionedRole: roleHere}, nil, nil, []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeEveryone}},
+		{"summary", outgoingMessage{content: "<@1>, you missed 2 reminders."}, nil, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, fake := newFakeDiscordMessenger(t)
+			if err := m.sendToChannel("channel", test.message); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fake.messages) != 1 {
+				t.Fatalf("expected a single message, got %+v", fake.messages)
+			}
+			sent := fake.messages[0]
+			if sent.Content != test.message.content || sent.AllowedMentions == nil {
+				t.Fatalf("unexpected message %+v", sent)
+			}
+
+			allowed := sent.AllowedMentions
+			if !slices.Equal(allowed.Users, test.users) || !slices.Equal(allowed.Roles, test.roles) || !slices.Equal(allowed.Parse, test.parse) {
+				t.Errorf("expected to notify the users %v, the roles %v and %v, got %+v", test.users, test.roles, test.parse, allowed)
+			}
+		})
+	}
+}
+
+// The replies to the commands only notify the author, even when they echo other mentions.
+func TestDiscordRepliesOnlyNotifyTheAuthor(t *testing.T) {
+	useMemoryStore(t)
+	m, fake := newFakeDiscordMessenger(t)
+	m.message = &discordgo.MessageCreate{Message: &discordgo.Message{ID: "10", ChannelID: "channel", GuildID: "guild"}}
+
+	cmd := &incomingCommand{messenger: m, author: "1", guildId: "guild", channelId: "channel"}
+	cmd.reply("Sorry, <@2> doesn't want to be reminded by others.")
+
+	if len(fake.messages) != 1 {
+		t.Fatalf("expected a single reply, got %+v", fake.messages)
+	}
+	sent := fake.messages[0]
+	if sent.Reference == nil || sent.Reference.MessageID != "10" {
+		t.Errorf("expected a reply to the command, got %+v", sent.Reference)
+	}
+	if allowed := sent.AllowedMentions; allowed == nil || !allowed.RepliedUser || len(allowed.Users) > 0 || len(allowed.Roles) > 0 || len(allowed.Parse) > 0 {
+		t.Errorf("expected only the author to be notified, got %+v", allowed)
+	}
+}
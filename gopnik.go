@@ -1,7 +1,6 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -15,29 +14,44 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 var (
 	token              = ""
 	remindersChannelId = ""
-	dbHandle           *sql.DB
+	slackToken         = ""
+	slackSigningSecret = ""
+	matrixHomeserver   = ""
+	matrixToken        = ""
+	store              ReminderStore
+	reminderScheduler  = newScheduler()
+	catchUp            = catchUpOnce
+	// How far in the future the reminders can be set, in durationSyntax.
+	maxHorizon = "10 years"
 )
 
-type rowToUpdate struct {
-	id      string
-	newTime time.Time
-	who     string
-}
-
-type eventState struct {
-	session *discordgo.Session
-	message *discordgo.MessageCreate
-}
+// The parts of the durations, e.g. `2 days`, `1.5 hours`, `an hour` or `30m`. The short `m` means minutes.
+// The article always needs the space, so that e.g. `and` isn't taken for `an` and `d`.
+const (
+	durationUnitSyntax = `years?|months?|weeks?|days?|hours?|hrs?|minutes?|mins?|seconds?|secs?|[ywdhms]`
+	durationPartSyntax = `(?:(\d+(?:\.\d+)?) ?|(an?) )(` + durationUnitSyntax + `)`
+	// One or more amounts with units, e.g. `2 weeks and 3 days`, `1 hour, 30 minutes` or `1h30m`. A bare space
+	// doesn't join the parts, so that e.g. `in 1 hour a week review` keeps `a week review` as the text.
+	durationSyntax = `(?:\d+(?:\.\d+)? ?|an? )(?:` + durationUnitSyntax + `)(?:(?:,? and |, )?(?:\d+(?:\.\d+)? ?|an? )(?:` + durationUnitSyntax + `))*`
+	// Like durationSyntax, but also joining the parts with a bare space, e.g. `1 hour 30 minutes`. Only for when
+	// nothing follows the duration.
+	looseDurationSyntax = `(?:\d+(?:\.\d+)? ?|an? )(?:` + durationUnitSyntax + `)(?:(?:,? and |, | )?(?:\d+(?:\.\d+)? ?|an? )(?:` + durationUnitSyntax + `))*`
+)
 
-func (es *eventState) reply(msg string) {
-	es.session.ChannelMessageSendReply(es.message.ChannelID, msg, es.message.Reference())
-}
+// The time syntaxes of `!remindme`, without the reminder text that follows them.
+// They're also accepted by `!editreminder <ID> time`.
+var (
+	absoluteTimeSyntax  = `on (\d{1,2})\.(\d{1,2})(?:\.(\d{4}))? at (\d{1,2})(?::(\d{1,2}))?(?: (AM|PM))?` + timezoneSyntax
+	relativeTimeSyntax  = `in (` + durationSyntax + `)`
+	recurringTimeSyntax = `every (?:(\d{1,2}) )?(hours?|days?|weeks?|weekday|monday|tuesday|wednesday|thursday|friday|saturday|sunday)` +
+		`(?: on (monday|tuesday|wednesday|thursday|friday|saturday|sunday))?(?: at (\d{1,2})(?::(\d{1,2}))?(?: (AM|PM))?)?` + timezoneSyntax
+	cronTimeSyntax = `cron "([^"]+)"` + timezoneSyntax
+)
 
 func isLeapYear(year int) bool {
 	if year%400 == 0 {
@@ -47,128 +61,335 @@ func isLeapYear(year int) bool {
 	return year%4 == 0 && year%100 != 0
 }
 
-func handlePendingReminders(es *eventState) {
-	rows, err := dbHandle.Query("SELECT * FROM Reminders WHERE who=? ORDER BY time", es.message.Author.ID)
+func handlePendingReminders(cmd *incomingCommand) {
+	// The DMs list the reminders from all the guilds, the guilds only their own ones.
+	guildId, _ := cmd.origin()
+	pending, err := store.listReminders(cmd.author, guildId)
 	if err != nil {
 		log.Println("Error querying the pending reminders:", err)
-		es.reply("Something went wrong while querying the pending reminders. Check the stderr output.")
+		cmd.reply("Something went wrong while querying the pending reminders. Check the stderr output.")
 		return
 	}
-	defer rows.Close()
 
 	reminders := make([]string, 0)
-	for rows.Next() {
-		var (
-			id        uint32
-			who       string
-			time      time.Time
-			toRemind  string
-			recurring bool
-		)
-
-		if err := rows.Scan(&id, &who, &time, &toRemind, &recurring); err != nil {
-			log.Println("Error scanning the row:", err)
-		}
-
-		if recurring {
-			reminders = append(reminders, fmt.Sprintf("*[ID: %d]* %s every day, next time on <t:%d>", id, toRemind, time.Unix()))
+	for _, reminder := range pending {
+		toRemind := cmd.language().describeTarget(cmd.messenger, reminder, cmd.author, reminder.toRemind)
+		if len(reminder.recurrence) > 0 {
+			rule, err := parseRecurrence(reminder.recurrence)
+			if err != nil {
+				log.Println("Error parsing the recurrence rule:", err)
+			}
+			reminders = append(reminders, cmd.sprintf("*[ID: %d]* %s %s, next time on %s", reminder.id, toRemind, cmd.language().describeRecurrence(rule), cmd.timestamp(reminder.time, reminder.loadLocation())))
 		} else {
-			reminders = append(reminders, fmt.Sprintf("*[ID: %d]* %s on <t:%d>", id, toRemind, time.Unix()))
+			reminders = append(reminders, cmd.sprintf("*[ID: %d]* %s on %s", reminder.id, toRemind, cmd.timestamp(reminder.time, reminder.loadLocation())))
 		}
 	}
-	if err = rows.Err(); err != nil {
-		log.Println("Error when iterating over the pending reminders:", err)
-		es.reply("Something went wrong while iterating over the pending reminders. Check the stderr output.")
-		return
-	}
 
 	if len(reminders) == 0 {
-		es.reply("You have no pending reminders.")
+		cmd.reply("You have no pending reminders.")
 		return
 	}
 
 	var pendingReminders strings.Builder
-	pendingReminders.WriteString("You have the following pending reminders:\n")
+	pendingReminders.WriteString(cmd.sprintf("You have the following pending reminders:\n"))
 	for idx, reminder := range reminders {
-		pendingReminders.WriteString(fmt.Sprintf("%d. Reminder %s.\n", idx+1, reminder))
+		pendingReminders.WriteString(cmd.sprintf("%d. Reminder %s.\n", idx+1, reminder))
 	}
-	pendingReminders.WriteString("\nTo remove a reminder, use `!rmreminder <ID>`, e.g. `!rmreminder 42`.")
+	pendingReminders.WriteString(cmd.sprintf("\nTo remove a reminder, use `!rmreminder <ID>`, e.g. `!rmreminder 42`."))
+	pendingReminders.WriteString(cmd.sprintf("\nTo change one, use `!editreminder <ID> text|time|tz ...`, e.g. `!editreminder 42 time in 2 days`."))
 
-	es.reply(pendingReminders.String())
+	cmd.reply(pendingReminders.String())
 }
 
-func handleTzpreferenceRegexMatch(es *eventState, matches []string) {
-	newTzPreference, err := time.LoadLocation(matches[1])
+func handleTzpreferenceRegexMatch(cmd *incomingCommand, matches []string) {
+	newTzPreference, errMsg, ok := lookupTimezone(cmd, matches[1])
+	if !ok {
+		cmd.reply(errMsg)
+		return
+	}
+
+	err := store.setTimezonePreference(cmd.author, newTzPreference.String())
 	if err != nil {
-		log.Println("Error loading the location:", err)
-		es.reply("Something went wrong while loading the location. Make sure it's correct or check the stderr output.")
+		log.Println("Error updating the database:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
 		return
 	}
 
-	var (
-		id                   string
-		who                  string
-		existingTzPreference string
-	)
+	cmd.reply(cmd.sprintf("Successfully set the preference to the %s timezone.", newTzPreference.String()))
+}
 
-	err = dbHandle.QueryRow("SELECT * FROM TimezonePreferences WHERE who=?", es.message.Author.ID).Scan(&id, &who, &existingTzPreference)
+func handleDeliverypreferenceRegexMatch(cmd *incomingCommand, matches []string) {
+	preference := deliveryPreference(matches[1])
+
+	err := store.setDeliveryPreference(cmd.author, preference)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			_, err = dbHandle.Exec("INSERT INTO TimezonePreferences VALUES(NULL,?,?)", es.message.Author.ID, newTzPreference.String())
-			if err != nil {
-				log.Println("Error inserting into the database:", err)
-				es.reply("Something went wrong while inserting to the DB. Check the stderr output.")
-				return
-			}
-		} else {
-			log.Println("Error querying for a previously saved preference:", err)
-			es.reply("Something went wrong while querying for a previously saved preference. Check the stderr output.")
-			return
-		}
+		log.Println("Error updating the database:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
+		return
 	}
 
-	_, err = dbHandle.Exec("UPDATE TimezonePreferences SET timezonePreference=? WHERE id=?", newTzPreference.String(), id)
+	switch preference {
+	case deliverDm:
+		cmd.reply("Successfully set the preference. From now on, I'll remind you in the DMs.")
+	case deliverHere:
+		cmd.reply("Successfully set the preference. From now on, I'll remind you in the channel you set the reminder in.")
+	default:
+		cmd.reply("Successfully set the preference. From now on, I'll remind you in the reminders channel of the server you set the reminder on.")
+	}
+}
+
+func handleClockpreferenceRegexMatch(cmd *incomingCommand, matches []string) {
+	preference := clockPreference(matches[1])
+
+	err := store.setClockPreference(cmd.author, preference)
 	if err != nil {
 		log.Println("Error updating the database:", err)
-		es.reply("Something went wrong while updating the DB. Check the stderr output.")
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
 		return
 	}
 
-	es.reply("Successfully set the preference.")
+	if preference == clock24h {
+		cmd.reply("Successfully set the preference. From now on, I'll show you the times on the 24-hour clock, e.g. 17:30.")
+	} else {
+		cmd.reply("Successfully set the preference. From now on, I'll show you the times on the 12-hour clock, e.g. 05:30 PM.")
+	}
 }
 
-func handleRmreminderRegexMatch(es *eventState, matches []string) {
-	id, _ := strconv.Atoi(matches[1])
-	if id > math.MaxUint32 {
-		es.reply(fmt.Sprintf("The ID is too big, has to be between 0 and %d.", math.MaxUint32))
+func handlePronounpreferenceRegexMatch(cmd *incomingCommand, matches []string) {
+	preference := pronounPreference(matches[1])
+
+	err := store.setPronounPreference(cmd.author, preference)
+	if err != nil {
+		log.Println("Error updating the database:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
+		return
+	}
+
+	if preference == pronounsKeep {
+		cmd.reply("Successfully set the preference. From now on, I'll remind you in your own words.")
+	} else {
+		cmd.reply("Successfully set the preference. From now on, I'll turn e.g. \"my\" into \"your\" when reminding you.")
+	}
+}
+
+func handleRemindpreferenceRegexMatch(cmd *incomingCommand, matches []string) {
+	preference := remindPreference(matches[1])
+
+	err := store.setRemindPreference(cmd.author, preference)
+	if err != nil {
+		log.Println("Error updating the database:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
 		return
 	}
 
-	var who string
-	err := dbHandle.QueryRow("SELECT who FROM Reminders WHERE id=?", id).Scan(&who)
-	if errors.Is(err, sql.ErrNoRows) {
-		es.reply("There isn't a reminder with that ID. Make sure you provided the correct one.")
+	if preference == remindByMe {
+		cmd.reply("Successfully set the preference. From now on, only you can set reminders for yourself. The ones already set by others are listed in `!reminders`, in case you want to remove them.")
+	} else {
+		cmd.reply("Successfully set the preference. From now on, anyone can set reminders for you with `!remind`.")
+	}
+}
+
+func handleLanguageRegexMatch(cmd *incomingCommand, matches []string) {
+	err := store.setLanguage(cmd.author, language(matches[1]))
+	if err != nil {
+		log.Println("Error updating the database:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
 		return
 	}
 
-	if es.message.Author.ID != who {
-		es.reply("You cannot remove someone else's reminders!")
+	// The reply already comes in the new language.
+	cmd.reply("Successfully set the preference. From now on, I'll talk to you in English.")
+}
+
+// Looks the reminder up and makes sure it belongs to the author, the action is used in the reply,
+// e.g. "remove". Both the reminded user and the one who set the reminder can touch it.
+// Returns false if the author can't touch the reminder, after replying why.
+func checkReminderOwnership(cmd *incomingCommand, idMatch string, action string) (reminder, bool) {
+	id, _ := strconv.Atoi(idMatch)
+	if id > math.MaxUint32 {
+		cmd.reply(cmd.sprintf("The ID is too big, has to be between 0 and %d.", math.MaxUint32))
+		return reminder{}, false
+	}
+
+	existing, err := store.getReminder(int64(id))
+	if errors.Is(err, errReminderNotFound) {
+		cmd.reply("There isn't a reminder with that ID. Make sure you provided the correct one.")
+		return reminder{}, false
+	} else if err != nil {
+		log.Println("Error querying the reminder:", err)
+		cmd.reply("Something went wrong while querying the reminder. Check the stderr output.")
+		return reminder{}, false
+	}
+
+	if cmd.author != existing.who && cmd.author != existing.creator {
+		cmd.reply(cmd.sprintf("You cannot %s someone else's reminders!", cmd.language().translate(action)))
+		return reminder{}, false
+	}
+
+	return existing, true
+}
+
+func handleRmreminderRegexMatch(cmd *incomingCommand, matches []string) {
+	existing, ok := checkReminderOwnership(cmd, matches[1], "remove")
+	if !ok {
 		return
 	}
 
-	_, err = dbHandle.Exec("DELETE FROM Reminders WHERE id=?", id)
+	err := store.deleteReminder(existing.id)
 	if err != nil {
 		log.Println("Error deleting the row:", err)
-		es.reply("Something went wrong while deleting the reminder. Check the stderr output.")
+		cmd.reply("Something went wrong while deleting the reminder. Check the stderr output.")
+		return
 	}
+	reminderScheduler.unschedule(existing.id)
 
-	es.reply("Successfully deleted the reminder.")
+	cmd.reply("Successfully deleted the reminder.")
 }
 
-func isAbsoluteDateValid(day int, month int, year int, hour int, minute int, currentYear int) (string, bool) {
+func handleEditreminderRegexMatch(cmd *incomingCommand, matches []string) {
+	existing, ok := checkReminderOwnership(cmd, matches[1], "edit")
+	if !ok {
+		return
+	}
+
+	field := matches[2]
+	value := matches[3]
+
+	switch field {
+	case "text":
+		// The text is in the creator's name, the reminded user could otherwise put words in their mouth.
+		if cmd.author != existing.creator {
+			cmd.reply("Only the one who set the reminder can change its text. You can still remove it with `!rmreminder`.")
+			return
+		}
+
+		if len(value) > 1500 {
+			cmd.reply("The maximum reminder length is 1500 characters, you naughty person.")
+			return
+		}
+
+		existing.toRemind = value
+		if existing.isPersonal() {
+			existing.toRemind = cmd.rewritePronouns(value)
+		}
+		err := store.updateReminder(existing)
+		if err != nil {
+			log.Println("Error updating the row:", err)
+			cmd.reply("Something went wrong while updating the reminder. Check the stderr output.")
+			return
+		}
+
+		cmd.reply(cmd.sprintf("Successfully edited the reminder. I'll remind you %s instead.", existing.toRemind))
+	case "time":
+		parsed, errMsg, ok := parseTimeSyntax(cmd, value)
+		if !ok {
+			cmd.reply(errMsg)
+			return
+		}
+
+		existing.time = parsed.targetTime
+		existing.recurrence = parsed.rule
+		existing.location = parsed.location.String()
+		existing.wallClock = parsed.wallClock
+		existing.delivered = false
+		err := store.updateReminder(existing)
+		if err != nil {
+			log.Println("Error updating the row:", err)
+			cmd.reply("Something went wrong while updating the reminder. Check the stderr output.")
+			return
+		}
+		reminderScheduler.schedule(existing.id, existing.time)
+
+		cmd.reply(cmd.sprintf("Successfully edited the reminder. I'll remind you %s instead.", parsed.description))
+	case "tz":
+		handleEditreminderTimezone(cmd, existing, value)
+	}
+}
+
+// Moves the reminder to another timezone, keeping its local time of day, e.g. a reminder for 9 AM
+// in Europe/Warsaw becomes a reminder for 9 AM in America/New_York.
+func handleEditreminderTimezone(cmd *incomingCommand, existing reminder, timezone string) {
+	newLocation, errMsg, ok := lookupTimezone(cmd, timezone)
+	if !ok {
+		cmd.reply(errMsg)
+		return
+	}
+
+	targetTime := existing.time
+	rule := existing.recurrence
+	location := existing.location
+	wallClock := existing.wallClock
+
+	if existing.delivered {
+		cmd.reply("This reminder was already delivered, set a new time for it with `!editreminder <ID> time ...` instead.")
+		return
+	}
+
+	oldLocation, err := time.LoadLocation(location)
+	if err != nil {
+		log.Println("Error loading the location:", err)
+		oldLocation = time.UTC
+	}
+
+	currentTime := time.Now()
+	var newTime time.Time
+	if len(rule) == 0 {
+		local := targetTime.In(oldLocation)
+		newTime = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), 0, 0, newLocation)
+		if newTime.Before(currentTime) {
+			cmd.reply("In this timezone the reminder would be in the past, who would've guessed?")
+			return
+		}
+	} else {
+		parsedRule, err := loadRecurrence(rule, location, wallClock)
+		if err != nil {
+			log.Println("Error parsing the recurrence rule:", err)
+			cmd.reply("Something went wrong while parsing the recurrence rule. Check the stderr output.")
+			return
+		}
+
+		switch {
+		case parsedRule.cronSchedule != nil:
+			parsedRule, errMsg, ok := recurrenceFromCron(cmd.language(), parsedRule.cronExpression(), newLocation)
+			if !ok {
+				cmd.reply(errMsg)
+				return
+			}
+			rule = parsedRule.String()
+			newTime = parsedRule.next(currentTime)
+		case parsedRule.unit == "hour":
+			// Hourly reminders don't stick to a time of day, only the following days are affected.
+			newTime = targetTime
+		default:
+			parsedRule.location = newLocation
+			local := targetTime.In(oldLocation)
+			newTime = parsedRule.atWallClock(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, newLocation))
+			for !newTime.After(currentTime) {
+				newTime = parsedRule.next(newTime)
+			}
+		}
+	}
+	newTime = newTime.UTC()
+
+	existing.time = newTime
+	existing.recurrence = rule
+	existing.location = newLocation.String()
+	err = store.updateReminder(existing)
+	if err != nil {
+		log.Println("Error updating the row:", err)
+		cmd.reply("Something went wrong while updating the reminder. Check the stderr output.")
+		return
+	}
+	reminderScheduler.schedule(existing.id, newTime)
+
+	cmd.reply(cmd.sprintf("Successfully moved the reminder to the %s timezone, next time on %s.", newLocation.String(), cmd.timestamp(newTime, newLocation)))
+}
+
+// The hour follows the 12-hour clock when the period (AM or PM) is given, and the 24-hour one otherwise.
+func isAbsoluteDateValid(cmd *incomingCommand, day int, month int, year int, hour int, minute int, period string, currentYear int) (string, bool) {
 	// Validate day and month.
 	if day == 0 || day > 31 {
-		return fmt.Sprintf("No month has %d days you silly goose.", day), false
+		return cmd.sprintf("No month has %d days you silly goose.", day), false
 	} else if month == 0 {
 		return "There is no 0th month my dear pumpkin.", false
 	} else if month > 12 {
@@ -183,19 +404,23 @@ func isAbsoluteDateValid(day int, month int, year int, hour int, minute int, cur
 		}
 
 		if day > daysInMonths[month-1] {
-			return fmt.Sprintf("There aren't %d days in this month.", day), false
+			return cmd.sprintf("There aren't %d days in this month.", day), false
 		}
 	}
 
 	// Validate year.
 	difference := year - currentYear
 	if difference < 0 || difference > 1 {
-		return fmt.Sprintf("The year has to be either %d or %d.", currentYear, currentYear+1), false
+		return cmd.sprintf("The year has to be either %d or %d.", currentYear, currentYear+1), false
 	}
 
 	// Validate hour.
-	if hour == 0 || hour > 12 {
-		return "The time has to follow the [12-hour clock](https://en.wikipedia.org/wiki/12-hour_clock).", false
+	if len(period) == 0 {
+		if hour > 23 {
+			return "There aren't that many hours in a day!", false
+		}
+	} else if hour == 0 || hour > 12 {
+		return "The time has to follow the [12-hour clock](https://en.wikipedia.org/wiki/12-hour_clock) when you add AM or PM.", false
 	}
 
 	// Validate minute.
@@ -207,43 +432,58 @@ func isAbsoluteDateValid(day int, month int, year int, hour int, minute int, cur
 }
 
 // Resolves the location with the following precedence:
-// 1. Explicitly specified in the command.
+// 1. Explicitly specified in the command, in any form lookupTimezone understands.
 // 2. Read from the TimezonePreferences table.
-// 3. Default (Europe/Warsaw).
-func resolveLocation(es *eventState, locationMatch string) (*time.Location, error) {
+// 3. The guild's default timezone.
+// 4. Default (Europe/Warsaw).
+// On failure, returns the message for the user.
+func resolveLocation(cmd *incomingCommand, locationMatch string) (*time.Location, string, bool) {
 	if len(locationMatch) > 0 {
-		return time.LoadLocation(locationMatch)
-	} else {
-		var (
-			id                   string
-			who                  string
-			existingTzPreference string
-		)
+		return lookupTimezone(cmd, unmarkTimezone(locationMatch))
+	}
 
-		err := dbHandle.QueryRow("SELECT * FROM TimezonePreferences WHERE who=?", es.message.Author.ID).Scan(&id, &who, &existingTzPreference)
-		if errors.Is(err, sql.ErrNoRows) {
-			return time.LoadLocation("Europe/Warsaw")
-		} else {
-			return time.LoadLocation(existingTzPreference)
-		}
+	name := defaultTimezone
+	existingTzPreference, err := store.timezonePreference(cmd.author)
+	if err != nil {
+		log.Println("Error resolving the location:", err)
+		return nil, "Couldn't resolve your location. Make sure you spelled it correctly or check the stderr output.", false
 	}
-}
 
-func handleAbsoluteRegexMatch(es *eventState, matches []string) {
-	toRemind := matches[8]
-	if len(toRemind) > 1500 {
-		es.reply("The maximum reminder length is 1500 characters, you naughty person.")
-		return
+	guildId, _ := cmd.origin()
+	if len(existingTzPreference) > 0 {
+		name = existingTzPreference
+	} else if guildTimezone := loadGuildSettings(guildId).defaultTimezone; len(guildTimezone) > 0 {
+		name = guildTimezone
 	}
 
-	location, err := resolveLocation(es, matches[7])
+	location, err := time.LoadLocation(name)
 	if err != nil {
 		log.Println("Error resolving the location:", err)
-		es.reply("Couldn't resolve your location. Make sure you spelled it correctly or check the stderr output.")
-		return
+		return nil, "Couldn't resolve your location. Make sure you spelled it correctly or check the stderr output.", false
 	}
 
-	currentTime := time.Now().In(location)
+	return location, "", true
+}
+
+// When and how often a reminder fires, parsed from one of the `!remindme` time syntaxes.
+type reminderTime struct {
+	targetTime time.Time
+	// Recurrence rule, empty for one-time reminders.
+	rule      string
+	location  *time.Location
+	wallClock string
+	// How the time is presented to the user, e.g. "on Monday, 23.12.2024 at 12:00 PM in the Europe/Warsaw timezone".
+	description string
+}
+
+// Parses the matches of absoluteTimeSyntax. On invalid input, returns the message for the user.
+func parseAbsoluteTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, errMsg, ok := resolveLocation(cmd, matches[7])
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	currentTime := timeNow().In(location)
 	currentYear := currentTime.Year()
 
 	day, _ := strconv.Atoi(matches[1])
@@ -265,15 +505,12 @@ func handleAbsoluteRegexMatch(es *eventState, matches []string) {
 		minute = 0
 	}
 
-	if errMsg, ok := isAbsoluteDateValid(day, month, year, hour, minute, currentYear); !ok {
-		es.reply(errMsg)
-		return
-	}
-
 	period := matches[6]
+	if errMsg, ok := isAbsoluteDateValid(cmd, day, month, year, hour, minute, period, currentYear); !ok {
+		return reminderTime{}, errMsg, false
+	}
 
-	// Hour in the 12-hour format is needed later for the information for the user,
-	// but the database expects the 24-hour format.
+	// The database expects the 24-hour format.
 	dbHour := hour
 	if period == "AM" && hour == 12 {
 		dbHour = 0
@@ -288,428 +525,730 @@ func handleAbsoluteRegexMatch(es *eventState, matches []string) {
 	)
 	if err != nil {
 		log.Println("Error parsing the time:", err)
-		es.reply("Something went wrong while parsing the time. Check the stderr output.")
-		return
+		return reminderTime{}, "Something went wrong while parsing the time. Check the stderr output.", false
 	}
 
 	targetTime = targetTime.UTC()
 	if targetTime.Before(currentTime.UTC()) {
-		es.reply("The date cannot be in the past, who would've guessed?")
-		return
+		return reminderTime{}, "The date cannot be in the past, who would've guessed?", false
 	}
 
-	_, err = dbHandle.Exec("INSERT INTO Reminders VALUES(NULL,?,?,?,0)", es.message.Author.ID, targetTime, strings.Replace(toRemind, " my ", " your ", -1))
-	if err != nil {
-		log.Println("Error inserting into the database:", err)
-		es.reply("Something went wrong while inserting to the DB. Check the stderr output.")
-		return
-	}
-
-	reply := fmt.Sprintf("Successfully added to the database. I'll remind you %s on %02d.%02d.%d at %02d:%02d %s in the %s timezone.",
-		toRemind, day, month, year, hour, minute, period, location.String())
-	es.reply(strings.Replace(reply, " my ", " your ", -1))
+	return reminderTime{
+		targetTime:  targetTime,
+		location:    location,
+		description: describeLocalTime(cmd, targetTime, location),
+	}, "", true
 }
 
-func parseRelativeRemindme(matches []string) (int, string, string, time.Time) {
-	var n int
-	if matches[1] == "a" || matches[1] == "an" {
-		n = 1
-	} else {
-		n, _ = strconv.Atoi(matches[1])
-	}
-
-	units, toRemind := matches[2], matches[3]
-
-	targetTime := time.Now().UTC()
-	switch units {
-	case "minute", "minutes":
-		targetTime = targetTime.Add(time.Minute * time.Duration(n))
-	case "hour", "hours":
-		targetTime = targetTime.Add(time.Hour * time.Duration(n))
-	case "day", "days":
-		targetTime = targetTime.AddDate(0, 0, n)
-	case "week", "weeks":
-		targetTime = targetTime.AddDate(0, 0, 7*n)
-	case "month", "months":
-		targetTime = targetTime.AddDate(0, n, 0)
-	default:
-		log.Println("Something went really wrong, we shouldn't be here.")
+var durationPartRegex = regexp.MustCompile(durationPartSyntax)
+
+// The furthest a parsed duration may reach, so that the sums can't overflow time.Duration.
+const maxDurationSeconds = 200 * 365 * 24 * 60 * 60
+
+// Adds the duration written in durationSyntax, e.g. `1 hour 30 minutes` or `1h30m`, to the time. The years, months,
+// weeks and days follow the calendar, and only the months and years have to be whole.
+// On invalid input, returns the message for the user.
+func addDuration(from time.Time, duration string) (time.Time, string, bool) {
+	var (
+		years   int
+		months  int
+		days    int
+		seconds float64
+	)
+	for _, part := range durationPartRegex.FindAllStringSubmatch(duration, -1) {
+		amount := 1.0
+		if len(part[1]) > 0 {
+			amount, _ = strconv.ParseFloat(part[1], 64)
+		}
+		if amount > 1000000 {
+			return time.Time{}, "That's way too far in the future, who would've guessed?", false
+		}
+		whole := amount == math.Trunc(amount)
+
+		switch part[3] {
+		case "year", "years", "y":
+			if !whole {
+				return time.Time{}, "A fraction of a year is hard to tell, use months or weeks instead.", false
+			}
+			years += int(amount)
+		case "month", "months":
+			if !whole {
+				return time.Time{}, "A fraction of a month is hard to tell, use weeks or days instead.", false
+			}
+			months += int(amount)
+		case "week", "weeks", "w":
+			if whole {
+				days += 7 * int(amount)
+			} else {
+				seconds += amount * 7 * 24 * 60 * 60
+			}
+		case "day", "days", "d":
+			if whole {
+				days += int(amount)
+			} else {
+				seconds += amount * 24 * 60 * 60
+			}
+		case "hour", "hours", "hr", "hrs", "h":
+			seconds += amount * 60 * 60
+		case "minute", "minutes", "min", "mins", "m":
+			seconds += amount * 60
+		default:
+			seconds += amount
+		}
 	}
 
-	return n, units, toRemind, targetTime
+	if seconds > maxDurationSeconds {
+		return time.Time{}, "That's way too far in the future, who would've guessed?", false
+	}
+
+	return from.AddDate(years, months, days).Add(time.Duration(seconds * float64(time.Second))), "", true
 }
 
-func handleRelativeRegexMatch(es *eventState, matches []string) {
-	n, units, toRemind, targetTime := parseRelativeRemindme(matches)
-	if len(toRemind) > 1500 {
-		es.reply("The maximum reminder length is 1500 characters.")
-		return
-	}
+// The reply for the reminders that would fire right away.
+const rightNowMessage = "That's right now, you silly goose."
 
-	if n == 0 {
-		es.reply(strings.Replace(fmt.Sprintf("Immediately reminding you %s, you silly goose.", toRemind), " my ", " your ", -1))
-		return
+// Parses the matches of relativeTimeSyntax. On invalid input, returns the message for the user.
+func parseRelativeTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	currentTime := timeNow().UTC()
+	targetTime, errMsg, ok := addDuration(currentTime, matches[1])
+	if !ok {
+		return reminderTime{}, errMsg, false
 	}
 
-	parsedToRemind := strings.Replace(toRemind, " my ", " your ", -1)
-	_, err := dbHandle.Exec("INSERT INTO Reminders VALUES(NULL,?,?,?,0)", es.message.Author.ID, targetTime, parsedToRemind)
-	if err != nil {
-		log.Println("Error inserting into the database:", err)
-		es.reply("Something went wrong while inserting to the DB. Check the stderr output.")
-		return
+	if targetTime.Sub(currentTime) < time.Second {
+		return reminderTime{}, rightNowMessage, false
 	}
 
-	es.reply(fmt.Sprintf("Successfully added to the database. I'll remind you in %d %s %s.", n, units, parsedToRemind))
+	return reminderTime{targetTime: targetTime, location: time.UTC, description: cmd.sprintf("in %s", matches[1])}, "", true
 }
 
-func handleRecurringRegexMatch(es *eventState, matches []string) {
-	toRemind := matches[5]
-	if len(toRemind) > 1500 {
-		es.reply("The maximum reminder length is 1500 characters, you naughty person.")
-		return
+// Parses the matches of recurringTimeSyntax. On invalid input, returns the message for the user.
+func parseRecurringTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	rule, errMsg, ok := recurrenceFromRemindme(cmd.language(), matches[1], matches[2], matches[3])
+	if !ok {
+		return reminderTime{}, errMsg, false
 	}
 
-	location, err := resolveLocation(es, matches[4])
-	if err != nil {
-		log.Println("Error resolving the location:", err)
-		es.reply("Couldn't resolve your location. Make sure you spelled it correctly or check the stderr output.")
-		return
+	location, errMsg, ok := resolveLocation(cmd, matches[7])
+	if !ok {
+		return reminderTime{}, errMsg, false
 	}
 
-	currentTime := time.Now().In(location)
+	currentTime := timeNow().In(location)
 
-	hour, _ := strconv.Atoi(matches[1])
+	// Without an explicit time, the reminder is anchored to the current time of day.
+	anchor := currentTime.Truncate(time.Minute)
 
-	var minute int
-	if len(matches[2]) > 0 {
-		minute, _ = strconv.Atoi(matches[2])
+	var (
+		hour   int
+		minute int
+		period string
+	)
+	if len(matches[4]) > 0 {
+		hour, _ = strconv.Atoi(matches[4])
+
+		if len(matches[5]) > 0 {
+			minute, _ = strconv.Atoi(matches[5])
+		} else {
+			minute = 0
+		}
+
+		period = matches[6]
+		if errMsg, ok := isAbsoluteDateValid(cmd, currentTime.Day(), int(currentTime.Month()), currentTime.Year(), hour, minute, period, currentTime.Year()); !ok {
+			return reminderTime{}, errMsg, false
+		}
+
+		// The database expects the 24-hour format.
+		dbHour := hour
+		if period == "AM" && hour == 12 {
+			dbHour = 0
+		} else if period == "PM" && hour < 12 {
+			dbHour += 12
+		}
+
+		var err error
+		anchor, err = time.ParseInLocation(
+			time.DateTime,
+			fmt.Sprintf("%d-%02d-%02d %02d:%02d:%02d", currentTime.Year(), currentTime.Month(), currentTime.Day(), dbHour, minute, 0),
+			location,
+		)
+		if err != nil {
+			log.Println("Error parsing the time:", err)
+			return reminderTime{}, "Something went wrong while parsing the time. Check the stderr output.", false
+		}
+	}
+
+	rule.location = location
+	if rule.unit != "hour" {
+		rule.wallClock = anchor.Format("15:04")
+	}
+
+	targetTime := rule.first(anchor, currentTime).UTC()
+
+	var description string
+	if len(matches[4]) > 0 {
+		description = cmd.sprintf("%s at %s in the %s timezone", cmd.language().describeRecurrence(rule), loadClockPreference(cmd.author).format(anchor), location.String())
 	} else {
-		minute = 0
+		description = cmd.sprintf("%s, starting on %s", cmd.language().describeRecurrence(rule), cmd.timestamp(targetTime, location))
 	}
 
-	if errMsg, ok := isAbsoluteDateValid(currentTime.Day(), int(currentTime.Month()), currentTime.Year(), hour, minute, currentTime.Year()); !ok {
-		es.reply(errMsg)
-		return
+	return reminderTime{
+		targetTime:  targetTime,
+		rule:        rule.String(),
+		location:    location,
+		wallClock:   rule.wallClock,
+		description: description,
+	}, "", true
+}
+
+// Parses the matches of cronTimeSyntax. On invalid input, returns the message for the user.
+func parseCronTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, errMsg, ok := resolveLocation(cmd, matches[2])
+	if !ok {
+		return reminderTime{}, errMsg, false
 	}
 
-	period := matches[3]
-	// Hour in the 12-hour format is needed later for the information for the user,
-	// but the database expects the 24-hour format.
-	dbHour := hour
-	if period == "AM" && hour == 12 {
-		dbHour = 0
-	} else if period == "PM" && hour < 12 {
-		dbHour += 12
+	rule, errMsg, ok := recurrenceFromCron(cmd.language(), matches[1], location)
+	if !ok {
+		return reminderTime{}, errMsg, false
 	}
 
-	targetTime, err := time.ParseInLocation(
-		time.DateTime,
-		fmt.Sprintf("%d-%02d-%02d %02d:%02d:%02d", currentTime.Year(), currentTime.Month(), currentTime.Day(), dbHour, minute, 0),
-		location,
-	)
-	if err != nil {
-		log.Println("Error parsing the time:", err)
-		es.reply("Something went wrong while parsing the time. Check the stderr output.")
-		return
+	targetTime := rule.next(timeNow()).UTC()
+	if targetTime.IsZero() {
+		return reminderTime{}, "This cron expression never fires, who would've guessed?", false
 	}
 
-	targetTime = targetTime.UTC()
-	if targetTime.Before(currentTime.UTC()) {
-		targetTime = targetTime.AddDate(0, 0, 1)
+	return reminderTime{
+		targetTime:  targetTime,
+		rule:        rule.String(),
+		location:    location,
+		description: cmd.sprintf("%s in the %s timezone, next time on %s", cmd.language().describeRecurrence(rule), location.String(), cmd.timestamp(targetTime, location)),
+	}, "", true
+}
+
+// Inserts the reminder set by the author and hands it over to the scheduler. The reminder is for the user,
+// or for the role in their name when the role isn't empty.
+func insertReminder(cmd *incomingCommand, who string, role string, toRemind string, parsed reminderTime) error {
+	guildId, channelId := cmd.origin()
+
+	var messageId string
+	if cmd.about != nil {
+		messageId = cmd.about.messageId
 	}
 
-	_, err = dbHandle.Exec("INSERT INTO Reminders VALUES(NULL,?,?,?,1)", es.message.Author.ID, targetTime, strings.Replace(toRemind, " my ", " your ", -1))
+	created := reminder{
+		who:        who,
+		creator:    cmd.author,
+		targetRole: role,
+		time:       parsed.targetTime,
+		toRemind:   toRemind,
+		recurrence: parsed.rule,
+		location:   parsed.location.String(),
+		wallClock:  parsed.wallClock,
+		guildId:    guildId,
+		channelId:  channelId,
+		messageId:  messageId,
+		platform:   cmd.messenger.platform(),
+	}
+	if err := store.createReminder(&created); err != nil {
+		return err
+	}
+
+	reminderScheduler.schedule(created.id, created.time)
+	return nil
+}
+
+// Inserts the reminder and confirms it to the author.
+func addReminder(cmd *incomingCommand, toRemind string, parsed reminderTime) {
+	// Only the reminder gets rewritten, the rest of the reply is already in the second person.
+	toRemind = cmd.rewritePronouns(toRemind)
+	err := insertReminder(cmd, cmd.author, "", toRemind, parsed)
 	if err != nil {
 		log.Println("Error inserting into the database:", err)
-		es.reply("Something went wrong while inserting to the DB. Check the stderr output.")
+		cmd.reply("Something went wrong while inserting to the DB. Check the stderr output.")
 		return
 	}
 
-	reply := fmt.Sprintf("Successfully added to the database. I'll remind you %s every day at %02d:%02d %s in the %s timezone.",
-		toRemind, hour, minute, period, location.String())
-	es.reply(strings.Replace(reply, " my ", " your ", -1))
+	cmd.reply(cmd.sprintf("Successfully added to the database. I'll remind you %s %s.", toRemind, parsed.description))
 }
 
-func messageCreate(session *discordgo.Session, message *discordgo.MessageCreate) {
-	// Ignore the bot's own messages.
-	if message.Author.ID == session.State.User.ID {
+// The replies to the invalid commands, which are also the keys of their translations.
+const (
+	configHelp = "Invalid `!config` syntax. Has to match this regex:\n" +
+		"`%s`\n\n" +
+		"For example:\n" +
+		"`!config` to show the current configuration\n" +
+		"`!config channel #reminders`\n" +
+		"`!config timezone America/New_York`\n" +
+		"`!config prefix ?`\n" +
+		"`!config roles @Members @Moderators`\n" +
+		"`!config pingroles @Moderators`\n\n" +
+		"Use `none` as the value to go back to the default."
+	editreminderHelp = "Invalid `!editreminder` syntax. Has to match this regex:\n" +
+		"`%s`\n\n" +
+		"For example:\n" +
+		"`!editreminder 42 text to buy two gifts for Aurora`\n" +
+		"`!editreminder 42 time on 24.12 at 9 AM`\n" +
+		"`!editreminder 42 tz America/New_York`"
+	remindmeHelp = "Invalid `!remindme` syntax. The time has to come first, followed by what to remind you about, e.g.:\n" +
+		"`!remindme in 2 days to buy a gift for Aurora`\n" +
+		"`!remindme in 2h30m to take the pizza out`\n" +
+		"`!remindme in 2 weeks and 3 days to renew the passport`\n" +
+		"`!remindme tomorrow at 9 to call mom`\n" +
+		"`!remindme next friday at noon about the lunch`\n" +
+		"`!remindme the day after tomorrow to water the plants`\n" +
+		"`!remindme this weekend at 10 to clean the flat`\n" +
+		"`!remindme at 17:45 to leave the office`\n" +
+		"`!remindme at 2026-12-23T17:30+01:00 to call the family`\n" +
+		"`!remindme on Dec 24 to wrap the presents`\n" +
+		"`!remindme end of day to send the report`\n" +
+		"`!remindme on 23.12 at 12 PM America/New_York that Christmas is tomorrow`\n" +
+		"`!remindme on 23.12 at 17:30 to start cooking`\n" +
+		"`!remindme every weekday at 10 AM about the standup`\n" +
+		"`!remindme every 2 weeks on friday at 3 PM to send the invoices`\n" +
+		"`!remindme cron \"0 9 1 * *\" Europe/London to pay the rent`\n\n" +
+		"⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯\n\n" +
+		"The hours without AM or PM follow the 24-hour clock. Without the time of day, it's 9:00. " +
+		"Use `!clockpreference 12h|24h` to choose how I show you the times.\n\n" +
+		"The timezone, when specified, can be an identifier from the [IANA Time Zone Database](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) " +
+		"(e.g. `America/New_York`), an abbreviation (e.g. `CET` or `PST`), a whole-hour UTC offset (e.g. `+02:00`, but not `+05:30`) or a city in parentheses or after `in` (e.g. `(Tokyo)` or `in Tokyo`). " +
+		"When not specified, first it checks whether you have a saved preference in the database, " +
+		"and if not, defaults to the server's timezone set with `!config timezone` or `Europe/Warsaw`.\n\n" +
+		"You can set your preference with:\n" +
+		"`!tzpreference <timezone>`\n\n" +
+		"For example:\n" +
+		"`!tzpreference Antarctica/South_Pole`"
+	remindHelp = "Invalid `!remind` syntax. The user, the role or `here` comes first, followed by the time and what to remind them about, e.g.:\n" +
+		"`!remind @Aurora tomorrow at 9 to call mom`\n" +
+		"`!remind @Moderators every monday at 10 AM about the weekly meeting`\n" +
+		"`!remind here in 15 minutes that the stream starts`\n\n" +
+		"The time follows the same syntaxes as in `!remindme`. Only the members with the roles set with `!config pingroles` " +
+		"and the server managers can remind roles or everyone here.\n\n" +
+		"Use `!remindpreference anyone|me` to choose whether others can set reminders for you."
+)
+
+// Handles the command in the `!` prefix syntax. The application commands get translated into it,
+// so that both input paths behave the same.
+func handleCommand(cmd *incomingCommand, content string) {
+	content = translateCommandName(content)
+
+	const configRegex = `^!config(?: (channel|timezone|prefix|roles|pingroles) (.+))?$`
+	configRegexCompiled := regexp.MustCompile(configRegex)
+
+	// The configuration is left accessible, so that the managers can't lock themselves out.
+	doesConfigRegexMatch := configRegexCompiled.MatchString(content)
+	if doesConfigRegexMatch {
+		handleConfigRegexMatch(cmd, configRegexCompiled.FindStringSubmatch(content))
 		return
 	}
 
-	// Ignore other bots' shenanigans.
-	if message.Author.Bot {
+	if strings.HasPrefix(content, "!config") {
+		cmd.reply(cmd.sprintf(configHelp, configRegex))
 		return
 	}
 
-	if !strings.HasPrefix(message.Content, "!") {
+	handler := matchCommand(content)
+	if handler == nil {
+		// Not a command, e.g. `!!!`, which isn't worth checking the roles for.
 		return
 	}
 
-	eventState := eventState{session, message}
-
-	if message.Content == "!reminders" {
-		handlePendingReminders(&eventState)
+	guildId, _ := cmd.origin()
+	if platformHasRoles(cmd.messenger.platform()) && !loadGuildSettings(guildId).allows(cmd.roles) && !cmd.messenger.canManageGuild(cmd) {
+		cmd.reply("Sorry, only the members with specific roles can use me on this server.")
 		return
 	}
 
-	const tzpreferenceRegex = `^!tzpreference ([a-zA-Z]+\/[a-zA-Z_]+)$`
+	handler(cmd)
+}
+
+// Returns the handler of the command, or nil if the content isn't one of the commands.
+func matchCommand(content string) func(cmd *incomingCommand) {
+	if content == "!reminders" {
+		return handlePendingReminders
+	}
+
+	const tzpreferenceRegex = `^!tzpreference (.+)$`
 	tzpreferenceRegexCompiled := regexp.MustCompile(tzpreferenceRegex)
 
-	doesTzpreferenceRegexMatch := tzpreferenceRegexCompiled.MatchString(message.Content)
+	doesTzpreferenceRegexMatch := tzpreferenceRegexCompiled.MatchString(content)
 	if doesTzpreferenceRegexMatch {
-		handleTzpreferenceRegexMatch(&eventState, tzpreferenceRegexCompiled.FindStringSubmatch(message.Content))
-		return
+		return func(cmd *incomingCommand) {
+			handleTzpreferenceRegexMatch(cmd, tzpreferenceRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	const deliverypreferenceRegex = `^!deliverypreference (dm|here|default)$`
+	deliverypreferenceRegexCompiled := regexp.MustCompile(deliverypreferenceRegex)
+
+	doesDeliverypreferenceRegexMatch := deliverypreferenceRegexCompiled.MatchString(content)
+	if doesDeliverypreferenceRegexMatch {
+		return func(cmd *incomingCommand) {
+			handleDeliverypreferenceRegexMatch(cmd, deliverypreferenceRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	const clockpreferenceRegex = `^!clockpreference (12h|24h)$`
+	clockpreferenceRegexCompiled := regexp.MustCompile(clockpreferenceRegex)
+
+	doesClockpreferenceRegexMatch := clockpreferenceRegexCompiled.MatchString(content)
+	if doesClockpreferenceRegexMatch {
+		return func(cmd *incomingCommand) {
+			handleClockpreferenceRegexMatch(cmd, clockpreferenceRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	const pronounpreferenceRegex = `^!pronounpreference (on|off)$`
+	pronounpreferenceRegexCompiled := regexp.MustCompile(pronounpreferenceRegex)
+
+	doesPronounpreferenceRegexMatch := pronounpreferenceRegexCompiled.MatchString(content)
+	if doesPronounpreferenceRegexMatch {
+		return func(cmd *incomingCommand) {
+			handlePronounpreferenceRegexMatch(cmd, pronounpreferenceRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	const remindpreferenceRegex = `^!remindpreference (anyone|me)$`
+	remindpreferenceRegexCompiled := regexp.MustCompile(remindpreferenceRegex)
+
+	doesRemindpreferenceRegexMatch := remindpreferenceRegexCompiled.MatchString(content)
+	if doesRemindpreferenceRegexMatch {
+		return func(cmd *incomingCommand) {
+			handleRemindpreferenceRegexMatch(cmd, remindpreferenceRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	const languageRegex = `^!language (en|pl)$`
+	languageRegexCompiled := regexp.MustCompile(languageRegex)
+
+	doesLanguageRegexMatch := languageRegexCompiled.MatchString(content)
+	if doesLanguageRegexMatch {
+		return func(cmd *incomingCommand) {
+			handleLanguageRegexMatch(cmd, languageRegexCompiled.FindStringSubmatch(content))
+		}
 	}
 
 	const rmreminderRegex = `^!rmreminder (\d+)$`
 	rmreminderRegexCompiled := regexp.MustCompile(rmreminderRegex)
 
-	doesRmrreminderRegexMatch := rmreminderRegexCompiled.MatchString(message.Content)
+	doesRmrreminderRegexMatch := rmreminderRegexCompiled.MatchString(content)
 	if doesRmrreminderRegexMatch {
-		handleRmreminderRegexMatch(&eventState, rmreminderRegexCompiled.FindStringSubmatch(message.Content))
-		return
+		return func(cmd *incomingCommand) {
+			handleRmreminderRegexMatch(cmd, rmreminderRegexCompiled.FindStringSubmatch(content))
+		}
 	}
 
-	const absoluteRemindmeRegex = `^!remindme on (\d{1,2})\.(\d{1,2})(?:\.(\d{4}))? at (\d{1,2})(?::(\d{1,2}))? (AM|PM) ?([a-zA-Z]+\/[a-zA-Z_]+)? (.+)`
-	const relativeRemindmeRegex = `^!remindme in (\d{1,2}|an?) (minutes?|hours?|days?|weeks?|months?) (.+)`
-	const recurringRemindmeRegex = `^!remindme every day at (\d{1,2})(?::(\d{1,2}))? (AM|PM) ?([a-zA-Z]+\/[a-zA-Z_]+)? (.+)`
-
-	absoluteRemindmeRegexCompiled := regexp.MustCompile(absoluteRemindmeRegex)
-	relativeRemindmeRegexCompiled := regexp.MustCompile(relativeRemindmeRegex)
-	recurringRemindmeRegexCompiled := regexp.MustCompile(recurringRemindmeRegex)
-
-	doesAbsoluteRegexMatch := absoluteRemindmeRegexCompiled.MatchString(message.Content)
-	doesRelativeRegexMatch := relativeRemindmeRegexCompiled.MatchString(message.Content)
-	doesRecurringRegexMatch := recurringRemindmeRegexCompiled.MatchString(message.Content)
-
-	if strings.HasPrefix(message.Content, "!remindme") && !doesAbsoluteRegexMatch && !doesRelativeRegexMatch && !doesRecurringRegexMatch {
-		eventState.reply(
-			"Invalid `!remindme` syntax. Has to match either of these regexes:\n" +
-				fmt.Sprintf("`%s`\n", absoluteRemindmeRegex) +
-				fmt.Sprintf("`%s`\n\n", relativeRemindmeRegex) +
-				"For example:\n" +
-				"`!remindme on 23.12 at 12 PM America/New_York that Christmas is tomorrow`\n" +
-				"`!remindme in 2 days to buy a gift for Aurora`\n\n" +
-				"⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯\n\n" +
-				"The timezone identifier (e.g. `America/New_York`), when specified, needs to match one of the identifiers from " +
-				"the [IANA Time Zone Database](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones). " +
-				"When not specified, first it checks whether you have a saved preference in the database, " +
-				"and if not, defaults to `Europe/Warsaw`.\n\n" +
-				"You can set your preference with:\n" +
-				fmt.Sprintf("`%s`\n\n", tzpreferenceRegex) +
-				"For example:\n" +
-				"`!tzpreference Antarctica/South_Pole`",
-		)
+	const editreminderRegex = `^!editreminder (\d+) (text|time|tz) (.+)$`
+	editreminderRegexCompiled := regexp.MustCompile(editreminderRegex)
+
+	doesEditreminderRegexMatch := editreminderRegexCompiled.MatchString(content)
+	if doesEditreminderRegexMatch {
+		return func(cmd *incomingCommand) {
+			handleEditreminderRegexMatch(cmd, editreminderRegexCompiled.FindStringSubmatch(content))
+		}
+	}
+
+	if strings.HasPrefix(content, "!editreminder") {
+		return func(cmd *incomingCommand) { cmd.reply(cmd.sprintf(editreminderHelp, editreminderRegex)) }
+	}
+
+	if input, ok := strings.CutPrefix(content, "!remindme "); ok {
+		return func(cmd *incomingCommand) { handleRemindme(cmd, input) }
+	}
+
+	if strings.HasPrefix(content, "!remindme") {
+		return replyRemindmeSyntax
+	}
+
+	if input, ok := strings.CutPrefix(content, "!remind "); ok {
+		return func(cmd *incomingCommand) { handleRemind(cmd, input) }
+	}
+
+	if content == "!remind" {
+		return func(cmd *incomingCommand) { cmd.reply(remindHelp) }
+	}
+
+	return nil
+}
+
+// Handles `!remindme <time> <text>`, whichever of the time syntaxes it uses.
+func handleRemindme(cmd *incomingCommand, input string) {
+	parsed, toRemind, errMsg, ok, matched := splitTimeExpression(cmd, input)
+	if !matched {
+		replyRemindmeSyntax(cmd)
 		return
 	}
 
-	if !doesAbsoluteRegexMatch && !doesRelativeRegexMatch && !doesRecurringRegexMatch {
+	if len(toRemind) > 1500 {
+		cmd.reply("The maximum reminder length is 1500 characters, you naughty person.")
 		return
 	}
 
-	if doesAbsoluteRegexMatch {
-		handleAbsoluteRegexMatch(&eventState, absoluteRemindmeRegexCompiled.FindStringSubmatch(message.Content))
+	if errMsg == rightNowMessage {
+		cmd.reply(cmd.sprintf("Immediately reminding you %s, you silly goose.", cmd.rewritePronouns(toRemind)))
 		return
 	}
 
-	if doesRelativeRegexMatch {
-		handleRelativeRegexMatch(&eventState, relativeRemindmeRegexCompiled.FindStringSubmatch(message.Content))
+	if !ok {
+		cmd.reply(errMsg)
 		return
 	}
 
-	handleRecurringRegexMatch(&eventState, recurringRemindmeRegexCompiled.FindStringSubmatch(message.Content))
+	addReminder(cmd, toRemind, parsed)
+}
+
+func replyRemindmeSyntax(cmd *incomingCommand) {
+	cmd.reply(remindmeHelp)
 }
 
-func handleReminders(botSession *discordgo.Session, ticker *time.Ticker) {
-	for currentTime := range ticker.C {
-		if _, err := os.Stat("./reminders.db"); errors.Is(err, os.ErrNotExist) {
-			log.Println("Database not bootstrapped yet, nothing to check.")
+// Sends the reminders with the given IDs, which the scheduler found to be due, through the messengers of their
+// platforms.
+func handleReminders(messengers map[string]Messenger, ids []int64) {
+	currentTime := time.Now()
+	due, err := store.pendingReminders(ids)
+	if err != nil {
+		log.Println("Error querying the due reminders:", err)
+		return
+	}
+
+	missed := missedReminders{}
+	preferences := make(map[string]deliveryPreference)
+	for _, reminder := range due {
+		// Moved to a later time in the meantime, the scheduler has it again.
+		if reminder.time.After(currentTime) {
 			continue
 		}
 
-		rows, err := dbHandle.Query("SELECT * FROM Reminders")
-		if err != nil {
-			log.Println("Error querying the rows when handling the reminders:", err)
-		}
-
-		rowsToDelete := []string{}
-		rowsToUpdate := []rowToUpdate{}
-		for rows.Next() {
-			var (
-				id        string
-				who       string
-				time      time.Time
-				toRemind  string
-				recurring bool
-			)
-
-			if err := rows.Scan(&id, &who, &time, &toRemind, &recurring); err != nil {
-				log.Println("Error scanning the row:", err)
+		// The reminders stay pending until their platform is enabled again, they're scheduled on the next start.
+		messenger, ok := messengers[reminder.platform]
+		if !ok {
+			continue
+		}
+
+		preference, ok := preferences[reminder.who]
+		if !ok {
+			preference, err = store.deliveryPreference(reminder.who)
+			if err != nil {
+				log.Println("Error querying the delivery preference:", err)
+				preference = deliverDefault
 			}
+			preferences[reminder.who] = preference
+		}
+		// A role or everyone here can only be pinged in the channel the reminder was set in.
+		if len(reminder.targetRole) > 0 {
+			preference = deliverHere
+		}
 
-			if currentTime.UTC().After(time) {
-				_, err = botSession.ChannelMessageSend(remindersChannelId, fmt.Sprintf("<@%s>, reminding you %s.", who, toRemind))
-				if err != nil {
-					fmt.Println(err)
-				}
+		about := messageRef{guildId: reminder.guildId, channelId: reminder.channelId, messageId: reminder.messageId}
+		defaultChannelId := loadGuildSettings(reminder.guildId).reminderChannel(reminder.platform, reminder.channelId)
+		toRemind := reminder.toRemind
+		if len(reminder.messageId) > 0 {
+			toRemind = fmt.Sprintf("%s: %s", toRemind, messenger.messageLink(about))
+		}
 
-				if recurring {
-					newTime := time.AddDate(0, 0, 1)
-					rowsToUpdate = append(rowsToUpdate, rowToUpdate{id: id, newTime: newTime, who: who})
-				} else {
-					rowsToDelete = append(rowsToDelete, id)
-				}
+		var err error
+		lateness := currentTime.Sub(reminder.time)
+		if lateness <= lateTolerance {
+			err = sendReminder(messenger, reminder.id, reminder.who, reminder.targetRole, preference, defaultChannelId, formatReminder(messenger, reminder, toRemind, ""), about)
+		} else if catchUp == catchUpAll {
+			err = sendReminder(messenger, reminder.id, reminder.who, reminder.targetRole, preference, defaultChannelId, formatReminder(messenger, reminder, toRemind, formatLateness(lateness)), about)
+		} else {
+			// The missed reminders of a role or everyone here end up in the summary of the user who set them.
+			toRemind = loadLanguage(reminder.who).describeTarget(messenger, reminder, reminder.who, toRemind)
+			missed.add(messenger, reminder.who, preference, defaultChannelId, toRemind, reminder.time, reminder.loadLocation(), lateness)
+		}
+		if err != nil {
+			log.Println("Error sending the reminder:", err)
+		}
 
+		if len(reminder.recurrence) == 0 {
+			if err := store.markDelivered(reminder.id); err != nil {
+				log.Println("Error marking the row as delivered:", err)
 			}
+			continue
 		}
-		rows.Close()
 
-		placeholders := make([]string, len(rowsToDelete))
-		for i := range placeholders {
-			placeholders[i] = "?"
+		rule, err := loadRecurrence(reminder.recurrence, reminder.location, reminder.wallClock)
+		if err != nil {
+			log.Println("Error loading the recurrence rule, treating the reminder as a one-time one:", err)
+			if err := store.markDelivered(reminder.id); err != nil {
+				log.Println("Error marking the row as delivered:", err)
+			}
+			continue
 		}
 
-		_, err = dbHandle.Exec(
-			fmt.Sprintf("DELETE FROM Reminders WHERE id IN (%s)", strings.Join(rowsToDelete, ",")),
-		)
-		if err != nil {
-			log.Println("Error deleting the rows:", err)
+		// Jump straight to the next future occurrence, even if the bot missed several of them.
+		newTime := rule.next(reminder.time)
+		for !newTime.IsZero() && !newTime.After(currentTime) {
+			newTime = rule.next(newTime)
 		}
 
-		for _, rowToUpdate := range rowsToUpdate {
-			_, err := dbHandle.Exec(`
-			UPDATE Reminders
-			SET time=?
-			WHERE id=?
-			`, rowToUpdate.newTime, rowToUpdate.id)
-			if err != nil {
-				log.Println("Error updating the row:", err)
-				botSession.ChannelMessageSend(remindersChannelId, fmt.Sprintf("<@%s>, couldn't update the recurring reminder. You might need to set it again.", rowToUpdate.who))
+		// Cron expressions can run out of occurrences, e.g. when they point to a specific year.
+		if newTime.IsZero() {
+			if err := store.deleteReminder(reminder.id); err != nil {
+				log.Println("Error deleting the row:", err)
 			}
+			continue
+		}
+
+		newTime = newTime.UTC()
+		if err := store.updateReminderTime(reminder.id, newTime); err != nil {
+			log.Println("Error updating the row:", err)
+			messenger.sendToChannel(defaultChannelId, outgoingMessage{
+				content:  loadLanguage(reminder.who).sprintf("%s, couldn't update the recurring reminder. You might need to set it again.", messenger.mention(reminder.who)),
+				mentions: []string{reminder.who},
+			})
+			continue
 		}
+
+		reminderScheduler.schedule(reminder.id, newTime)
+	}
+
+	missed.send(catchUp)
+
+	// Nobody is going to snooze a reminder delivered a week ago.
+	err = store.purgeDelivered(currentTime.UTC().AddDate(0, 0, -7))
+	if err != nil {
+		log.Println("Error deleting the old delivered rows:", err)
 	}
 }
 
-func bootstrapDb() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "reminders.db")
+// Fills the scheduler with all the pending reminders.
+func loadPendingReminders() error {
+	pending, err := store.pendingDueTimes()
 	if err != nil {
-		return db, err
+		return err
 	}
 
-	row := db.QueryRow("PRAGMA user_version;")
-	if row == nil {
-		return db, errors.New("PRAGMA user_version not found")
+	for _, reminder := range pending {
+		reminderScheduler.schedule(reminder.id, reminder.time)
 	}
-	var userVersion int
-	if err = row.Scan(&userVersion); err != nil {
-		return db, err
+
+	return nil
+}
+
+// Reads the configuration and opens the store. Not an init function, so that `gopnik migrate` doesn't need the token.
+func setUp() {
+	// The bot can run on Discord, Slack and Matrix at once.
+	token = os.Getenv("GOPNIK_TOKEN")
+	slackToken = os.Getenv("SLACK_BOT_TOKEN")
+	matrixToken = os.Getenv("MATRIX_ACCESS_TOKEN")
+	if len(token) == 0 && len(slackToken) == 0 && len(matrixToken) == 0 {
+		log.Fatalln("Bot token not found. Make sure to set the GOPNIK_TOKEN, SLACK_BOT_TOKEN or MATRIX_ACCESS_TOKEN environment variable.")
 	}
 
-	switch userVersion {
-	// Base schema.
-	case 0:
-		tx, err := db.Begin()
-		if err != nil {
-			return db, err
-		}
-		defer tx.Rollback()
+	slackSigningSecret = os.Getenv("SLACK_SIGNING_SECRET")
+	if len(slackToken) > 0 && len(slackSigningSecret) == 0 {
+		log.Fatalln("Slack signing secret not found. Make sure to set the SLACK_SIGNING_SECRET environment variable.")
+	}
 
-		_, err = tx.Exec(`
-		CREATE TABLE IF NOT EXISTS Reminders (
-			id INTEGER NOT NULL PRIMARY KEY,
-			who TEXT NOT NULL,
-			time DATETIME NOT NULL,
-			toRemind TEXT NOT NULL
-		);`)
-		if err != nil {
-			return db, err
-		}
+	matrixHomeserver = os.Getenv("MATRIX_HOMESERVER")
+	if len(matrixToken) > 0 && len(matrixHomeserver) == 0 {
+		log.Fatalln("Matrix homeserver not found. Make sure to set the MATRIX_HOMESERVER environment variable, e.g. to https://matrix.org.")
+	}
 
-		_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS TimezonePreferences (
-			id INTEGER NOT NULL PRIMARY KEY,
-			who TEXT NOT NULL,
-			timezonePreference TEXT NOT NULL
-		);`)
-		if err != nil {
-			return db, err
-		}
+	// Optional, the guilds can set their own channels with `!config channel`.
+	remindersChannelId = os.Getenv("REMINDERS_CHANNEL")
 
-		_, err = tx.Exec("PRAGMA user_version = 1;")
+	var err error
+	if policy := os.Getenv("GOPNIK_CATCHUP_POLICY"); len(policy) > 0 {
+		catchUp, err = parseCatchUpPolicy(policy)
 		if err != nil {
-			return db, err
+			log.Fatalln("Invalid GOPNIK_CATCHUP_POLICY:", err)
 		}
+	}
 
-		if err = tx.Commit(); err != nil {
-			return db, err
+	if horizon := os.Getenv("GOPNIK_MAX_HORIZON"); len(horizon) > 0 {
+		if !regexp.MustCompile("^" + looseDurationSyntax + "$").MatchString(horizon) {
+			log.Fatalln("Invalid GOPNIK_MAX_HORIZON, expected a duration like `5 years` or `90 days`:", horizon)
+		}
+		if _, errMsg, ok := addDuration(time.Now(), horizon); !ok {
+			log.Fatalln("Invalid GOPNIK_MAX_HORIZON:", errMsg)
 		}
+		maxHorizon = horizon
+	}
 
-		fallthrough
-	// Support recurring reminders.
-	case 1:
-		tx, err := db.Begin()
-		if err != nil {
-			return db, err
+	store, err = openStore(os.Getenv("GOPNIK_DATABASE_URL"))
+	if err != nil {
+		log.Fatalln("Error bootstrapping the database:", err)
+	}
+
+	if err = loadPendingReminders(); err != nil {
+		store.close()
+		log.Fatalln("Error loading the pending reminders:", err)
+	}
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatalln("Error migrating the database:", err)
 		}
-		defer tx.Rollback()
+		return
+	}
+
+	setUp()
+	defer store.close()
+
+	messengers := make(map[string]Messenger)
 
-		_, err = tx.Exec("ALTER TABLE Reminders ADD recurring INTEGER NOT NULL DEFAULT 0")
+	if len(token) > 0 {
+		botSession, err := discordgo.New("Bot " + token)
 		if err != nil {
-			return db, err
+			log.Fatalln("Error creating the bot session:", err)
 		}
 
-		_, err = tx.Exec("PRAGMA user_version = 2;")
+		botSession.AddHandler(messageCreate)
+		botSession.AddHandler(interactionCreate)
+
+		botSession.Identify.Intents = discordgo.IntentsGuildMessages
+
+		err = botSession.Open()
 		if err != nil {
-			return db, err
+			log.Fatalln("Error opening the WebSocket connection:", err)
 		}
+		defer botSession.Close()
 
-		if err = tx.Commit(); err != nil {
-			return db, err
+		if err = registerApplicationCommands(botSession); err != nil {
+			log.Println("Error registering the application commands, only the `!` prefix commands will work:", err)
 		}
-	}
 
-	return db, nil
-}
-
-func init() {
-	token = os.Getenv("GOPNIK_TOKEN")
-	if len(token) == 0 {
-		log.Fatalln("Bot token not found. Make sure to set the GOPNIK_TOKEN environment variable.")
+		messengers[platformDiscord] = &discordMessenger{session: botSession}
 	}
 
-	remindersChannelId = os.Getenv("REMINDERS_CHANNEL")
-	if len(remindersChannelId) == 0 {
-		log.Fatalln("Reminders channel ID not found. Make sure to set the REMINDERS_CHANNEL environment variable.")
-	}
-
-	var err error
-	dbHandle, err = bootstrapDb()
-	if err != nil {
-		dbHandle.Close()
-		log.Fatalln("Error bootstrapping the database:", err)
-	}
-}
+	if len(slackToken) > 0 {
+		slack, err := newSlackMessenger(slackToken, slackApiUrl)
+		if err != nil {
+			log.Fatalln("Error connecting to Slack:", err)
+		}
 
-func main() {
-	defer dbHandle.Close()
+		address := os.Getenv("SLACK_LISTEN_ADDRESS")
+		if len(address) == 0 {
+			address = ":3000"
+		}
+		go func() {
+			log.Fatalln("Error serving the Slack requests:", slack.listen(address, slackSigningSecret))
+		}()
 
-	botSession, err := discordgo.New("Bot " + token)
-	if err != nil {
-		log.Fatalln("Error creating the bot session:", err)
+		messengers[platformSlack] = slack
 	}
 
-	botSession.AddHandler(messageCreate)
+	stopMatrix := make(chan struct{})
+	if len(matrixToken) > 0 {
+		matrix, err := newMatrixMessenger(matrixHomeserver, matrixToken)
+		if err != nil {
+			log.Fatalln("Error connecting to the Matrix homeserver:", err)
+		}
 
-	botSession.Identify.Intents = discordgo.IntentsGuildMessages
+		go matrix.listen(stopMatrix)
 
-	err = botSession.Open()
-	if err != nil {
-		log.Fatalln("Error opening the WebSocket connection:", err)
+		messengers[platformMatrix] = matrix
 	}
-	defer botSession.Close()
 
-	ticker := time.NewTicker(time.Minute)
-	go handleReminders(botSession, ticker)
+	stopScheduler := make(chan struct{})
+	go reminderScheduler.run(stopScheduler, func(ids []int64) {
+		handleReminders(messengers, ids)
+	})
 
 	fmt.Println("Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
@@ -717,5 +1256,6 @@ func main() {
 	<-sc
 
 	fmt.Println("Shutting down...")
-	ticker.Stop()
+	close(stopScheduler)
+	close(stopMatrix)
 }
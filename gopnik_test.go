@@ -0,0 +1,480 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Stores the reminder as if it was set in the `channel` channel of the `guild` guild, on Discord unless told
+// otherwise.
+func createTestReminder(t *testing.T, r reminder) reminder {
+	t.Helper()
+
+	if len(r.creator) == 0 {
+		r.creator = r.who
+	}
+	if len(r.location) == 0 {
+		r.location = "UTC"
+	}
+	if len(r.platform) == 0 {
+		r.platform = platformDiscord
+	}
+	r.guildId, r.channelId = "guild", "channel"
+	if err := store.createReminder(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestHandleRemindersSendsOnlyTheGivenIds(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	due := createTestReminder(t, reminder{who: "aurora", time: now.Add(-time.Second), toRemind: "to buy milk"})
+	notPopped := createTestReminder(t, reminder{who: "aurora", time: now.Add(-time.Second), toRemind: "to call mom"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{due.id})
+
+	_, sent, _ := messenger.take()
+	if len(sent) != 1 {
+		t.Fatalf("expected a single message, got %+v", sent)
+	}
+	if sent[0].to != "channel" || sent[0].message.content != "<@aurora>, reminding you to buy milk." {
+		t.Errorf("unexpected message %+v", sent[0])
+	}
+	if sent[0].message.reminderId != due.id || !slices.Equal(sent[0].message.mentions, []string{"aurora"}) || len(sent[0].message.mentionedRole) > 0 {
+		t.Errorf("unexpected buttons or mentions %+v", sent[0].message)
+	}
+
+	if delivered, _ := store.getReminder(due.id); !delivered.delivered {
+		t.Error("the sent reminder should be marked as delivered")
+	}
+	if pending, _ := store.getReminder(notPopped.id); pending.delivered {
+		t.Error("the reminder the scheduler didn't pop shouldn't be delivered")
+	}
+}
+
+func TestHandleRemindersSkipsTheMovedAndTheDeliveredOnes(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	moved := createTestReminder(t, reminder{who: "aurora", time: now.Add(time.Hour), toRemind: "to buy milk"})
+	delivered := createTestReminder(t, reminder{who: "aurora", time: now.Add(-time.Second), toRemind: "to call mom", delivered: true})
+	otherPlatform := createTestReminder(t, reminder{who: "aurora", time: now.Add(-time.Second), toRemind: "to water the plants", platform: platformSlack})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{moved.id, delivered.id, otherPlatform.id})
+
+	if _, sent, _ := messenger.take(); len(sent) > 0 {
+		t.Errorf("expected no messages, got %+v", sent)
+	}
+	if pending, _ := store.getReminder(otherPlatform.id); pending.delivered {
+		t.Error("the reminder of a disabled platform should stay pending")
+	}
+}
+
+func TestHandleRemindersReschedulesTheRecurringOnes(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	// Missed a few occurrences while the bot was offline, only the latest one is late.
+	recurring := createTestReminder(t, reminder{who: "aurora", time: now.Add(-3*time.Hour - 30*time.Second), toRemind: "to stretch", recurrence: "1 hour"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{recurring.id})
+
+	_, sent, _ := messenger.take()
+	if len(sent) != 1 {
+		t.Fatalf("expected a single summary, got %+v", sent)
+	}
+
+	updated, err := store.getReminder(recurring.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.delivered || !updated.time.After(now) || updated.time.Sub(now) > time.Hour {
+		t.Errorf("expected the next occurrence within the hour, got %v (delivered: %v)", updated.time, updated.delivered)
+	}
+	if dueTime, ok := reminderScheduler.nextDueTime(); !ok || !dueTime.Equal(updated.time) {
+		t.Errorf("expected the scheduler to have the next occurrence at %v, got %v", updated.time, dueTime)
+	}
+}
+
+func TestHandleRemindersSendsEveryMissedOneWithCatchUpAll(t *testing.T) {
+	useMemoryStore(t)
+	catchUp = catchUpAll
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	onTime := createTestReminder(t, reminder{who: "aurora", time: now.Add(-30 * time.Second), toRemind: "to call mom"})
+	late := createTestReminder(t, reminder{who: "aurora", time: now.Add(-2*time.Hour - 10*time.Minute), toRemind: "to stretch"})
+	veryLate := createTestReminder(t, reminder{who: "bruno", creator: "aurora", time: now.Add(-3 * 24 * time.Hour), toRemind: "to renew the passport"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{onTime.id, late.id, veryLate.id})
+
+	_, sent, _ := messenger.take()
+	// Oldest first, the reminders set for someone else still mention who set them.
+	expected := []string{
+		"<@bruno>, <@aurora> asked me to remind you to renew the passport (delivered 3d late).",
+		"<@aurora>, reminding you to stretch (delivered 2h 10m late).",
+		"<@aurora>, reminding you to call mom.",
+	}
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d messages, got %+v", len(expected), sent)
+	}
+	for i, message := range sent {
+		if message.message.content != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], message.message.content)
+		}
+	}
+
+	for _, id := range []int64{onTime.id, late.id, veryLate.id} {
+		if delivered, err := store.getReminder(id); err != nil || !delivered.delivered {
+			t.Errorf("expected the reminder %d to be delivered, got %+v (%v)", id, delivered, err)
+		}
+	}
+}
+
+func TestHandleRemindersSummarizesTheMissedOnesWithCatchUpOnce(t *testing.T) {
+	useMemoryStore(t)
+	catchUp = catchUpOnce
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	onTime := createTestReminder(t, reminder{who: "aurora", time: now.Add(-30 * time.Second), toRemind: "to call mom"})
+	late := createTestReminder(t, reminder{who: "aurora", time: now.Add(-2 * time.Hour), toRemind: "to stretch"})
+	forRole := createTestReminder(t, reminder{who: "aurora", targetRole: "moderators", time: now.Add(-90 * time.Minute), toRemind: "to check @everyone's reports"})
+	forBruno := createTestReminder(t, reminder{who: "bruno", creator: "aurora", time: now.Add(-26 * time.Hour), toRemind: "to water the plants"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{onTime.id, late.id, forRole.id, forBruno.id})
+
+	_, sent, _ := messenger.take()
+	// The reminders on time go out right away, the summaries after them.
+	expected := []string{
+		"<@aurora>, reminding you to call mom.",
+		"<@bruno>, I was offline for a while, so I'm late with reminding you:\n" +
+			"- from <@aurora> to water the plants, due on " + messenger.timestamp(forBruno.time, time.UTC, clock12h) + " (1d 2h ago)",
+		// The reminders of a role end up in the summary of the user who set them.
+		"<@aurora>, I was offline for a while, so I'm late with reminding you:\n" +
+			"- to stretch, due on " + messenger.timestamp(late.time, time.UTC, clock12h) + " (2h ago)\n" +
+			"- for <@&moderators> to check @\u200beveryone's reports, due on " + messenger.timestamp(forRole.time, time.UTC, clock12h) + " (1h 30m ago)",
+	}
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d messages, got %+v", len(expected), sent)
+	}
+	for i, message := range sent {
+		if message.to != "channel" || message.message.content != expected[i] {
+			t.Errorf("expected %q in the channel, got %q in %q", expected[i], message.message.content, message.to)
+		}
+	}
+
+	for _, id := range []int64{onTime.id, late.id, forRole.id, forBruno.id} {
+		if delivered, err := store.getReminder(id); err != nil || !delivered.delivered {
+			t.Errorf("expected the reminder %d to be delivered, got %+v (%v)", id, delivered, err)
+		}
+	}
+}
+
+func TestHandleRemindersSkipsTheMissedOnesWithCatchUpSkip(t *testing.T) {
+	useMemoryStore(t)
+	catchUp = catchUpSkip
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	onTime := createTestReminder(t, reminder{who: "aurora", time: now.Add(-30 * time.Second), toRemind: "to call mom"})
+	late := createTestReminder(t, reminder{who: "aurora", time: now.Add(-2 * time.Hour), toRemind: "to stretch"})
+	recurring := createTestReminder(t, reminder{who: "aurora", time: now.Add(-25 * time.Minute), toRemind: "to drink water", recurrence: "1 hour"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{onTime.id, late.id, recurring.id})
+
+	_, sent, _ := messenger.take()
+	expected := []string{
+		"<@aurora>, reminding you to call mom.",
+		"<@aurora>, I was offline for a while and skipped these reminders of yours:\n" +
+			"- to stretch, due on " + messenger.timestamp(late.time, time.UTC, clock12h) + " (2h ago)\n" +
+			"- to drink water, due on " + messenger.timestamp(recurring.time, time.UTC, clock12h) + " (25m ago)",
+	}
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d messages, got %+v", len(expected), sent)
+	}
+	for i, message := range sent {
+		if message.message.content != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], message.message.content)
+		}
+	}
+
+	// The skipped reminders are done with all the same, the recurring ones move on to the next occurrence.
+	if skipped, err := store.getReminder(late.id); err != nil || !skipped.delivered {
+		t.Errorf("expected the skipped reminder to be delivered, got %+v (%v)", skipped, err)
+	}
+	if updated, err := store.getReminder(recurring.id); err != nil || updated.delivered || !updated.time.Equal(recurring.time.Add(time.Hour)) {
+		t.Errorf("expected the recurring reminder in %v, got %+v (%v)", recurring.time.Add(time.Hour), updated, err)
+	}
+}
+
+func TestHandleRemindersMentionsTheTargetRole(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	now := time.Now().UTC()
+
+	forRole := createTestReminder(t, reminder{who: "aurora", targetRole: "moderators", time: now, toRemind: "to check @everyone's reports"})
+	forHere := createTestReminder(t, reminder{who: "aurora", targetRole: roleHere, time: now, toRemind: "to vote"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{forRole.id, forHere.id})
+
+	_, sent, _ := messenger.take()
+	if len(sent) != 2 {
+		t.Fatalf("expected two messages, got %+v", sent)
+	}
+
+	expected := []struct {
+		content string
+		role    string
+	}{
+		{"<@&moderators>, <@aurora> asked me to remind you to check @\u200beveryone's reports.", "moderators"},
+		{"@here, <@aurora> asked me to remind you to vote.", roleHere},
+	}
+	for i, message := range sent {
+		if message.to != "channel" || message.message.content != expected[i].content {
+			t.Errorf("unexpected message %+v", message)
+		}
+		if message.message.mentionedRole != expected[i].role || len(message.message.mentions) > 0 {
+			t.Errorf("expected only the %q role to be mentioned, got %+v", expected[i].role, message.message)
+		}
+	}
+}
+
+func TestHandleRemindersFollowsTheDeliveryPreference(t *testing.T) {
+	useMemoryStore(t)
+	messenger := newRecordingMessenger()
+	messenger.closedDms["bruno"] = true
+	now := time.Now().UTC()
+
+	for _, who := range []string{"aurora", "bruno"} {
+		if err := store.setDeliveryPreference(who, deliverDm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	toAurora := createTestReminder(t, reminder{who: "aurora", time: now, toRemind: "to buy milk"})
+	toBruno := createTestReminder(t, reminder{who: "bruno", time: now, toRemind: "to call mom"})
+
+	handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{toAurora.id, toBruno.id})
+
+	_, sent, dms := messenger.take()
+	if len(dms) != 1 || dms[0].to != "aurora" || dms[0].message.content != "<@aurora>, reminding you to buy milk." {
+		t.Errorf("expected a DM to aurora, got %+v", dms)
+	}
+	if len(sent) != 1 || sent[0].to != "channel" || sent[0].message.content != "<@bruno>, reminding you to call mom."+dmFallbackNote {
+		t.Errorf("expected bruno's reminder in the channel with the fallback note, got %+v", sent)
+	}
+}
+
+func TestHandleCommandRemindsTheTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		reply    string
+		expected string
+		mentions []string
+		role     string
+	}{
+		{
+			name:     "yourself",
+			content:  "!remindme in 2 hours to call my mom",
+			reply:    "Successfully added to the database. I'll remind you to call your mom in 2 hours.",
+			expected: "<@aurora>, reminding you to call your mom.",
+			mentions: []string{"aurora"},
+		},
+		{
+			name:     "another user",
+			content:  "!remind <@bruno> in 2 hours to call @here back",
+			reply:    "Successfully added to the database. I'll remind <@bruno> to call @here back in 2 hours.",
+			expected: "<@bruno>, <@aurora> asked me to remind you to call @\u200bhere back.",
+			mentions: []string{"bruno"},
+		},
+		{
+			name:     "a role",
+			content:  "!remind <@&moderators> in 2 hours to check <@bruno>'s reports",
+			reply:    "Successfully added to the database. I'll remind <@&moderators> to check <@bruno>'s reports in 2 hours.",
+			expected: "<@&moderators>, <@aurora> asked me to remind you to check <@bruno>'s reports.",
+			role:     "moderators",
+		},
+		{
+			name:     "everyone here",
+			content:  "!remind here in 2 hours to vote",
+			reply:    "Successfully added to the database. I'll remind @here to vote in 2 hours.",
+			expected: "@here, <@aurora> asked me to remind you to vote.",
+			role:     roleHere,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useMemoryStore(t)
+			messenger := newRecordingMessenger()
+			messenger.managers["aurora"] = true
+			cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+
+			now := time.Now()
+			previous := timeNow
+			timeNow = func() time.Time { return now.Add(-2*time.Hour - time.Second) }
+			handleCommand(cmd, test.content)
+			timeNow = previous
+
+			replies, _, _ := messenger.take()
+			if len(replies) != 1 || replies[0] != test.reply {
+				t.Fatalf("expected the reply %q, got %q", test.reply, replies)
+			}
+
+			pending, err := store.dueBefore(now)
+			if err != nil || len(pending) != 1 {
+				t.Fatalf("expected a single due reminder, got %+v and %v", pending, err)
+			}
+			handleReminders(map[string]Messenger{platformDiscord: messenger}, []int64{pending[0].id})
+
+			_, sent, _ := messenger.take()
+			if len(sent) != 1 {
+				t.Fatalf("expected a single message, got %+v", sent)
+			}
+
+			message := sent[0].message
+			if sent[0].to != "channel" || message.content != test.expected {
+				t.Errorf("expected %q in the channel, got %q in %q", test.expected, message.content, sent[0].to)
+			}
+			if !slices.Equal(message.mentions, test.mentions) || message.mentionedRole != test.role {
+				t.Errorf("expected to notify %v and the %q role, got %v and %q", test.mentions, test.role, message.mentions, message.mentionedRole)
+			}
+		})
+	}
+}
+
+func TestHandleCommandChecksTheRolesOfTheCommandsOnly(t *testing.T) {
+	useMemoryStore(t)
+	if err := saveGuildSetting("guild", "roles", "1001"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		roles   []string
+		manager bool
+		replies []string
+		checked bool
+	}{
+		{name: "not a command", content: "!!!", replies: nil},
+		{name: "an unknown command", content: "!remindhim in 2 hours to call mom", replies: nil},
+		{name: "without the role", content: "!reminders", replies: []string{"Sorry, only the members with specific roles can use me on this server."}, checked: true},
+		{name: "with the role", content: "!reminders", roles: []string{"1001"}, replies: []string{"You have no pending reminders."}},
+		{name: "a manager", content: "!reminders", manager: true, replies: []string{"You have no pending reminders."}, checked: true},
+		{name: "the configuration", content: "!config channel <#42>", replies: []string{"Only the members with the Manage Server permission can change the configuration!"}, checked: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			messenger := newRecordingMessenger()
+			messenger.managers["aurora"] = test.manager
+			cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel", roles: test.roles}
+
+			handleCommand(cmd, test.content)
+
+			replies, sent, dms := messenger.take()
+			if !slices.Equal(replies, test.replies) || len(sent) > 0 || len(dms) > 0 {
+				t.Errorf("expected the replies %q, got %q and the messages %+v %+v", test.replies, replies, sent, dms)
+			}
+			if checked := messenger.managerChecks > 0; checked != test.checked {
+				t.Errorf("expected the permissions to be checked: %v, got %d checks", test.checked, messenger.managerChecks)
+			}
+		})
+	}
+}
+
+// Only Discord tells the roles of the author, so the roles can't lock out the members on the other platforms.
+func TestHandleCommandSkipsTheRolesWithoutThem(t *testing.T) {
+	for _, platform := range []string{platformSlack, platformMatrix} {
+		t.Run(platform, func(t *testing.T) {
+			useMemoryStore(t)
+			// Set before `!config roles` was turned down on the platforms without the roles.
+			if err := saveGuildSetting("guild", "roles", "1001"); err != nil {
+				t.Fatal(err)
+			}
+
+			messenger := newRecordingMessenger()
+			messenger.platformName = platform
+			cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+			handleCommand(cmd, "!reminders")
+
+			replies, _, _ := messenger.take()
+			if expected := []string{"You have no pending reminders."}; !slices.Equal(replies, expected) {
+				t.Errorf("expected the replies %q, got %q", expected, replies)
+			}
+			if messenger.managerChecks > 0 {
+				t.Errorf("expected the permissions not to be checked, got %d checks", messenger.managerChecks)
+			}
+		})
+	}
+}
+
+func TestConfigRolesOnlyWorksWithTheRoles(t *testing.T) {
+	tests := []struct {
+		platform string
+		content  string
+		reply    string
+		setting  func(settings guildSettings) []string
+	}{
+		{
+			platform: platformDiscord,
+			content:  "!config roles <@&1001>",
+			reply:    "Successfully set the roles. From now on, only their members and the server managers can use the commands.",
+			setting:  func(settings guildSettings) []string { return settings.allowedRoles },
+		},
+		{
+			platform: platformDiscord,
+			content:  "!config pingroles <@&1001>",
+			reply:    "Successfully set the roles. From now on, their members and the server managers can remind roles or everyone here.",
+			setting:  func(settings guildSettings) []string { return settings.pingRoles },
+		},
+		{
+			platform: platformSlack,
+			content:  "!config roles <@&1001>",
+			reply:    "I can't see the roles of the members here, so `!config roles` only works on Discord.",
+			setting:  func(settings guildSettings) []string { return settings.allowedRoles },
+		},
+		{
+			platform: platformMatrix,
+			content:  "!config roles 1001",
+			reply:    "I can't see the roles of the members here, so `!config roles` only works on Discord.",
+			setting:  func(settings guildSettings) []string { return settings.allowedRoles },
+		},
+		{
+			platform: platformSlack,
+			content:  "!config pingroles 1001",
+			reply:    "I can't see the roles of the members here, so `!config pingroles` only works on Discord.",
+			setting:  func(settings guildSettings) []string { return settings.pingRoles },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.platform+" "+test.content, func(t *testing.T) {
+			useMemoryStore(t)
+			messenger := newRecordingMessenger()
+			messenger.platformName = test.platform
+			messenger.managers["aurora"] = true
+			cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+			handleCommand(cmd, test.content)
+
+			replies, _, _ := messenger.take()
+			if len(replies) != 1 || replies[0] != test.reply {
+				t.Errorf("expected the reply %q, got %q", test.reply, replies)
+			}
+
+			roles := test.setting(loadGuildSettings("guild"))
+			if set := slices.Equal(roles, []string{"1001"}); set != (test.platform == platformDiscord) {
+				t.Errorf("expected the roles to be set only on Discord, got %q", roles)
+			}
+		})
+	}
+}
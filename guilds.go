@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultPrefix = "!"
+	// Used when neither the command, the user nor the guild specify a timezone.
+	defaultTimezone = "Europe/Warsaw"
+)
+
+// Per-guild configuration stored in the GuildSettings table and changed with `!config`.
+// The zero values mean the defaults.
+type guildSettings struct {
+	// Where the reminders are sent, unless the user prefers otherwise. Falls back to the REMINDERS_CHANNEL.
+	channelId string
+	// Used for the members without a timezone preference.
+	defaultTimezone string
+	prefix          string
+	// When non-empty, only the members with one of these roles can use the commands.
+	allowedRoles []string
+	// The members with one of these roles can remind other roles and everyone in the channel with `!remind`.
+	// The server managers always can.
+	pingRoles []string
+}
+
+// Settings are read on every message, so they're cached until changed with `!config`.
+var (
+	guildSettingsCache   = make(map[string]guildSettings)
+	guildSettingsCacheMu sync.Mutex
+)
+
+// Returns the settings of the guild. Messages in the DMs don't belong to any guild and always get the defaults.
+func loadGuildSettings(guildId string) guildSettings {
+	if len(guildId) == 0 {
+		return guildSettings{prefix: defaultPrefix}
+	}
+
+	guildSettingsCacheMu.Lock()
+	defer guildSettingsCacheMu.Unlock()
+
+	if settings, ok := guildSettingsCache[guildId]; ok {
+		return settings
+	}
+
+	settings, err := store.guildSettings(guildId)
+	if err != nil {
+		// Don't cache the defaults, the next message will try again.
+		log.Println("Error querying the guild settings:", err)
+		return guildSettings{prefix: defaultPrefix}
+	}
+
+	if len(settings.prefix) == 0 {
+		settings.prefix = defaultPrefix
+	}
+
+	guildSettingsCache[guildId] = settings
+	return settings
+}
+
+func saveGuildSetting(guildId string, setting string, value string) error {
+	err := store.setGuildSetting(guildId, setting, value)
+	if err != nil {
+		return err
+	}
+
+	guildSettingsCacheMu.Lock()
+	delete(guildSettingsCache, guildId)
+	guildSettingsCacheMu.Unlock()
+
+	return nil
+}
+
+// Returns the channel where the reminders set in the origin channel are sent by default.
+func (s guildSettings) reminderChannel(platform string, originChannelId string) string {
+	if len(s.channelId) > 0 {
+		return s.channelId
+	}
+
+	// The REMINDERS_CHANNEL is a Discord channel.
+	if platform == platformDiscord && len(remindersChannelId) > 0 {
+		return remindersChannelId
+	}
+
+	return originChannelId
+}
+
+// Only Discord tells the roles of the command's author, see incomingCommand.roles. On the other platforms, the roles
+// set with `!config` would lock out everyone but the server managers.
+func platformHasRoles(platform string) bool {
+	return platform == platformDiscord
+}
+
+func (s guildSettings) allows(roles []string) bool {
+	return len(s.allowedRoles) == 0 || hasAnyRole(roles, s.allowedRoles)
+}
+
+func (s guildSettings) canPing(roles []string) bool {
+	return hasAnyRole(roles, s.pingRoles)
+}
+
+func hasAnyRole(roles []string, wanted []string) bool {
+	for _, role := range roles {
+		if slices.Contains(wanted, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Formats the roles as mentions separated with commas.
+func roleMentions(roles []string) string {
+	mentions := make([]string, len(roles))
+	for i, role := range roles {
+		mentions[i] = fmt.Sprintf("<@&%s>", role)
+	}
+
+	return strings.Join(mentions, ", ")
+}
+
+// Parses the roles given as mentions or IDs separated with spaces, returning them joined with commas.
+func parseRoles(value string) (string, bool) {
+	roles := []string{}
+	for _, role := range strings.Fields(value) {
+		roleMatches := roleMentionRegex.FindStringSubmatch(role)
+		if roleMatches == nil {
+			return "", false
+		}
+		roles = append(roles, roleMatches[1]+roleMatches[2])
+	}
+
+	return strings.Join(roles, ","), true
+}
+
+var (
+	// Slack adds the channel's name to the mention, e.g. `<#C024BE91L|general>`.
+	channelMentionRegex = regexp.MustCompile(`^(?:<#(\w+)(?:\|[^>]*)?>|(\w+))$`)
+	roleMentionRegex    = regexp.MustCompile(`^(?:<@&(\d+)>|(\d+))$`)
+)
+
+func handleConfigRegexMatch(cmd *incomingCommand, matches []string) {
+	guildId, _ := cmd.origin()
+	if len(guildId) == 0 {
+		cmd.reply("There's nothing to configure in the DMs, you silly goose.")
+		return
+	}
+
+	if !cmd.messenger.canManageGuild(cmd) {
+		cmd.reply("Only the members with the Manage Server permission can change the configuration!")
+		return
+	}
+
+	setting := matches[1]
+	value := strings.TrimSpace(matches[2])
+
+	if len(setting) == 0 {
+		settings := loadGuildSettings(guildId)
+
+		var config strings.Builder
+		config.WriteString(cmd.language().translate("The current configuration:\n"))
+		if len(settings.channelId) > 0 {
+			config.WriteString(cmd.sprintf("- channel: <#%s>\n", settings.channelId))
+		} else if cmd.messenger.platform() == platformDiscord && len(remindersChannelId) > 0 {
+			config.WriteString(cmd.sprintf("- channel: not set, using <#%s>\n", remindersChannelId))
+		} else {
+			config.WriteString(cmd.language().translate("- channel: not set, using the channel the reminder was set in\n"))
+		}
+		if len(settings.defaultTimezone) > 0 {
+			config.WriteString(cmd.sprintf("- timezone: `%s`\n", settings.defaultTimezone))
+		} else {
+			config.WriteString(cmd.sprintf("- timezone: not set, using `%s`\n", defaultTimezone))
+		}
+		config.WriteString(cmd.sprintf("- prefix: `%s`\n", settings.prefix))
+		if len(settings.allowedRoles) > 0 {
+			config.WriteString(cmd.sprintf("- roles: %s\n", roleMentions(settings.allowedRoles)))
+		} else {
+			config.WriteString(cmd.language().translate("- roles: not set, everyone can use the commands\n"))
+		}
+		if len(settings.pingRoles) > 0 {
+			config.WriteString(cmd.sprintf("- pingroles: %s", roleMentions(settings.pingRoles)))
+		} else {
+			config.WriteString(cmd.language().translate("- pingroles: not set, only the server managers can remind roles or everyone here"))
+		}
+
+		cmd.reply(config.String())
+		return
+	}
+
+	if (setting == "roles" || setting == "pingroles") && !platformHasRoles(cmd.messenger.platform()) {
+		cmd.reply(cmd.sprintf("I can't see the roles of the members here, so `!config %s` only works on Discord.", setting))
+		return
+	}
+
+	var (
+		stored string
+		reply  string
+	)
+	switch setting {
+	case "channel":
+		if value == "none" {
+			reply = "Successfully reset the reminders channel."
+			break
+		}
+
+		channelMatches := channelMentionRegex.FindStringSubmatch(value)
+		if channelMatches == nil {
+			cmd.reply("The channel has to be a mention or an ID, e.g. `!config channel #reminders`.")
+			return
+		}
+		stored = channelMatches[1] + channelMatches[2]
+
+		channelGuildId, err := cmd.messenger.channelGuild(stored)
+		if err != nil || channelGuildId != guildId {
+			cmd.reply("I can't see this channel. Make sure it's on this server and I have access to it.")
+			return
+		}
+		reply = cmd.sprintf("Successfully set the reminders channel to <#%s>.", stored)
+	case "timezone":
+		if value == "none" {
+			reply = cmd.sprintf("Successfully reset the default timezone to `%s`.", defaultTimezone)
+			break
+		}
+
+		location, errMsg, ok := lookupTimezone(cmd, value)
+		if !ok {
+			cmd.reply(errMsg)
+			return
+		}
+		stored = location.String()
+		reply = cmd.sprintf("Successfully set the default timezone to `%s`.", stored)
+	case "prefix":
+		if value == "none" {
+			reply = cmd.sprintf("Successfully reset the prefix to `%s`.", defaultPrefix)
+			break
+		}
+
+		if len(value) > 5 || strings.ContainsAny(value, " \t\n`") {
+			cmd.reply("The prefix has to be at most 5 characters long, without any spaces or backticks.")
+			return
+		}
+		stored = value
+		reply = cmd.sprintf("Successfully set the prefix to `%s`. The slash commands still work as usual.", stored)
+	case "roles":
+		if value == "none" {
+			reply = "Successfully reset the roles, everyone can use the commands now."
+			break
+		}
+
+		roles, ok := parseRoles(value)
+		if !ok {
+			cmd.reply("The roles have to be mentions or IDs separated with spaces, e.g. `!config roles @Members @Moderators`.")
+			return
+		}
+		stored = roles
+		reply = "Successfully set the roles. From now on, only their members and the server managers can use the commands."
+	case "pingroles":
+		if value == "none" {
+			reply = "Successfully reset the roles, only the server managers can remind roles or everyone here now."
+			break
+		}
+
+		roles, ok := parseRoles(value)
+		if !ok {
+			cmd.reply("The roles have to be mentions or IDs separated with spaces, e.g. `!config pingroles @Moderators`.")
+			return
+		}
+		stored = roles
+		reply = "Successfully set the roles. From now on, their members and the server managers can remind roles or everyone here."
+	}
+
+	if err := saveGuildSetting(guildId, setting, stored); err != nil {
+		log.Println("Error updating the guild settings:", err)
+		cmd.reply("Something went wrong while updating the DB. Check the stderr output.")
+		return
+	}
+
+	cmd.reply(reply)
+}
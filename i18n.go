@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// The languages of the replies, set per user with `!language`.
+type language string
+
+const (
+	languageEnglish language = "en"
+	languagePolish  language = "pl"
+)
+
+// Returns the user's language, falling back to English if it can't be read.
+func loadLanguage(who string) language {
+	lang, err := store.language(who)
+	if err != nil {
+		log.Println("Error querying the language:", err)
+		return languageEnglish
+	}
+
+	return lang
+}
+
+// Translates the English message. The messages missing from the catalog stay in English.
+func (lang language) translate(msg string) string {
+	if translated, ok := catalog[lang][msg]; ok {
+		return translated
+	}
+
+	return msg
+}
+
+// Formats the message after translating the English format. The translations keep the verbs of the original,
+// reordering them with the explicit argument indexes if needed.
+func (lang language) sprintf(format string, args ...any) string {
+	return fmt.Sprintf(lang.translate(format), args...)
+}
+
+// The Polish names of the commands, e.g. `!przypomnij jutro o 9 ...`.
+var polishCommands = map[string]string{
+	"!przypomnij":    "!remindme",
+	"!przypomnienia": "!reminders",
+	"!usuń":          "!rmreminder",
+	"!zmień":         "!editreminder",
+	"!język":         "!language",
+}
+
+// Replaces the Polish name of the command with the English one, leaving the arguments as they are.
+func translateCommandName(content string) string {
+	name, args, hasArgs := strings.Cut(content, " ")
+	english, ok := polishCommands[name]
+	if !ok {
+		return content
+	}
+
+	if hasArgs {
+		return english + " " + args
+	}
+	return english
+}
+
+var polishOnWeekday = map[time.Weekday]string{
+	time.Monday: "w poniedziałek", time.Tuesday: "we wtorek", time.Wednesday: "w środę", time.Thursday: "w czwartek",
+	time.Friday: "w piątek", time.Saturday: "w sobotę", time.Sunday: "w niedzielę",
+}
+
+var polishEveryWeekday = map[time.Weekday]string{
+	time.Monday: "w każdy poniedziałek", time.Tuesday: "w każdy wtorek", time.Wednesday: "w każdą środę",
+	time.Thursday: "w każdy czwartek", time.Friday: "w każdy piątek", time.Saturday: "w każdą sobotę",
+	time.Sunday: "w każdą niedzielę",
+}
+
+var polishMonths = [...]string{
+	"styczeń", "luty", "marzec", "kwiecień", "maj", "czerwiec",
+	"lipiec", "sierpień", "wrzesień", "październik", "listopad", "grudzień",
+}
+
+// The weekday as used in "on Monday", e.g. "w poniedziałek" in Polish.
+func (lang language) onWeekday(weekday time.Weekday) string {
+	if lang == languagePolish {
+		return polishOnWeekday[weekday]
+	}
+
+	return weekday.String()
+}
+
+func (lang language) monthName(month time.Month) string {
+	if lang == languagePolish {
+		return polishMonths[month-1]
+	}
+
+	return month.String()
+}
+
+// Picks the Polish plural form for the number, e.g. 1 godzina, 2 godziny, 5 godzin.
+func polishPlural(n int, one string, few string, many string) string {
+	if n == 1 {
+		return one
+	}
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+		return few
+	}
+
+	return many
+}
+
+// Human-readable form of the rule in the language, see recurrence.describe.
+func (lang language) describeRecurrence(r recurrence) string {
+	if lang != languagePolish {
+		return r.describe()
+	}
+
+	if r.cronSchedule != nil {
+		return fmt.Sprintf("według harmonogramu cron `%s`", r.cronExpression())
+	}
+
+	if r.unit == "day" && len(r.weekdays) == len(workingDays) {
+		return "w każdy dzień roboczy"
+	}
+
+	if len(r.weekdays) > 0 && r.interval == 1 {
+		return polishEveryWeekday[r.weekdays[0]]
+	}
+
+	var every string
+	switch {
+	case r.interval == 1 && r.unit == "hour":
+		every = "co godzinę"
+	case r.interval == 1 && r.unit == "day":
+		every = "codziennie"
+	case r.interval == 1:
+		every = "co tydzień"
+	case r.unit == "hour":
+		every = fmt.Sprintf("co %d %s", r.interval, polishPlural(r.interval, "godzinę", "godziny", "godzin"))
+	case r.unit == "day":
+		every = fmt.Sprintf("co %d dni", r.interval)
+	default:
+		every = fmt.Sprintf("co %d %s", r.interval, polishPlural(r.interval, "tydzień", "tygodnie", "tygodni"))
+	}
+
+	if len(r.weekdays) == 0 {
+		return every
+	}
+
+	return every + " " + polishOnWeekday[r.weekdays[0]]
+}
+
+// The translations of the messages, keyed by the English original. The formats have to keep the verbs
+// of the original, so that the arguments fit.
+var catalog = map[language]map[string]string{
+	languagePolish: {
+		// The commands.
+		remindmeHelp: "Niepoprawna składnia `!remindme`. Najpierw podaj czas, a potem o czym mam ci przypomnieć, np.:\n" +
+			"`!przypomnij za 2 dni kupić prezent dla Aurory`\n" +
+			"`!przypomnij za 2 godziny i 30 minut wyjąć pizzę`\n" +
+			"`!przypomnij za pół godziny wyłączyć piekarnik`\n" +
+			"`!przypomnij jutro o 9 zadzwonić do mamy`\n" +
+			"`!przypomnij pojutrze podlać kwiaty`\n" +
+			"`!przypomnij w przyszły piątek w południe o obiedzie`\n" +
+			"`!przypomnij w ten weekend o 10 posprzątać mieszkanie`\n" +
+			"`!przypomnij o 17:45 wyjść z biura`\n" +
+			"`!przypomnij 24 grudnia zapakować prezenty`\n" +
+			"`!remindme on 23.12 at 17:30 Europe/Warsaw zacząć gotować`\n" +
+			"`!remindme every weekday at 10 AM o spotkaniu`\n" +
+			"`!remindme cron \"0 9 1 * *\" Europe/London zapłacić czynsz`\n\n" +
+			"⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯\n\n" +
+			"Rozumiem też wszystkie angielskie składnie, np. `in 2 days` czy `tomorrow at 9`. " +
+			"Godziny bez AM i PM są w zegarze 24-godzinnym. Bez godziny przypomnę ci o 9:00. " +
+			"Użyj `!clockpreference 12h|24h`, żeby wybrać, jak mam ci pokazywać godziny.\n\n" +
+			"Strefa czasowa, jeśli ją podasz, może być identyfikatorem z [bazy stref czasowych IANA](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) " +
+			"(np. `Europe/Warsaw`), skrótem (np. `CET` albo `PST`), przesunięciem względem UTC o pełne godziny (np. `+02:00`, ale nie `+05:30`) albo miastem w nawiasie lub po `in` (np. `(Warszawa)` albo `in Warszawa`). " +
+			"Jeśli jej nie podasz, użyję twojej zapisanej preferencji, a jeśli jej nie masz, " +
+			"strefy serwera ustawionej przez `!config timezone` albo `Europe/Warsaw`.\n\n" +
+			"Preferencję możesz ustawić przez:\n" +
+			"`!tzpreference <strefa czasowa>`\n\n" +
+			"Na przykład:\n" +
+			"`!tzpreference Antarctica/South_Pole`",
+		configHelp: "Niepoprawna składnia `!config`. Musi pasować do tego wyrażenia regularnego:\n" +
+			"`%s`\n\n" +
+			"Na przykład:\n" +
+			"`!config`, żeby zobaczyć obecną konfigurację\n" +
+			"`!config channel #przypomnienia`\n" +
+			"`!config timezone Europe/Warsaw`\n" +
+			"`!config prefix ?`\n" +
+			"`!config roles @Członkowie @Moderatorzy`\n" +
+			"`!config pingroles @Moderatorzy`\n\n" +
+			"Użyj `none` jako wartości, żeby wrócić do domyślnej.",
+		editreminderHelp: "Niepoprawna składnia `!editreminder`. Musi pasować do tego wyrażenia regularnego:\n" +
+			"`%s`\n\n" +
+			"Na przykład:\n" +
+			"`!editreminder 42 text kupić dwa prezenty dla Aurory`\n" +
+			"`!editreminder 42 time 24 grudnia o 9`\n" +
+			"`!editreminder 42 tz Europe/Warsaw`",
+		remindHelp: "Niepoprawna składnia `!remind`. Najpierw użytkownik, rola albo `here`, potem czas i o czym przypomnieć, np.:\n" +
+			"`!remind @Aurora jutro o 9 zadzwonić do mamy`\n" +
+			"`!remind @Moderatorzy every monday at 10 AM o cotygodniowym spotkaniu`\n" +
+			"`!remind here za 15 minut, że zaczyna się stream`\n\n" +
+			"Czas ma te same składnie co w `!remindme`. Rolom i wszystkim tutaj mogą przypominać tylko członkowie z rolami " +
+			"ustawionymi przez `!config pingroles` i zarządcy serwera.\n\n" +
+			"Użyj `!remindpreference anyone|me`, żeby wybrać, czy inni mogą ustawiać ci przypomnienia.",
+		"Sorry, only the members with specific roles can use me on this server.": "Wybacz, na tym serwerze mogą mnie używać tylko członkowie z określonymi rolami.",
+		"I don't know this command, the bot might need to be restarted.":         "Nie znam tej komendy, może trzeba mnie zrestartować.",
+
+		// The listings.
+		"You have no pending reminders.":              "Nie masz żadnych oczekujących przypomnień.",
+		"You have the following pending reminders:\n": "Masz następujące oczekujące przypomnienia:\n",
+		"%d. Reminder %s.\n":                          "%d. Przypomnienie %s.\n",
+		"for %s %s":                                   "dla %s %s",
+		"from %s %s":                                  "od %s %s",
+		"*[ID: %d]* %s on %s":                         "*[ID: %d]* %s, %s",
+		"*[ID: %d]* %s %s, next time on %s":           "*[ID: %d]* %s %s, następnym razem %s",
+		"\nTo remove a reminder, use `!rmreminder <ID>`, e.g. `!rmreminder 42`.":                              "\nŻeby usunąć przypomnienie, użyj `!rmreminder <ID>`, np. `!rmreminder 42`.",
+		"\nTo change one, use `!editreminder <ID> text|time|tz ...`, e.g. `!editreminder 42 time in 2 days`.": "\nŻeby je zmienić, użyj `!editreminder <ID> text|time|tz ...`, np. `!editreminder 42 time za 2 dni`.",
+		"Something went wrong while querying the pending reminders. Check the stderr output.":                 "Coś poszło nie tak przy pobieraniu oczekujących przypomnień. Sprawdź wyjście stderr.",
+
+		// The preferences.
+		"Successfully set the preference.":                                                                                                                                                   "Pomyślnie ustawiono preferencję.",
+		"Successfully set the preference. From now on, I'll remind you in the DMs.":                                                                                                          "Pomyślnie ustawiono preferencję. Od teraz będę ci przypominać w wiadomościach prywatnych.",
+		"Successfully set the preference. From now on, I'll remind you in the channel you set the reminder in.":                                                                              "Pomyślnie ustawiono preferencję. Od teraz będę ci przypominać na kanale, na którym ustawisz przypomnienie.",
+		"Successfully set the preference. From now on, I'll remind you in the reminders channel of the server you set the reminder on.":                                                      "Pomyślnie ustawiono preferencję. Od teraz będę ci przypominać na kanale przypomnień serwera, na którym ustawisz przypomnienie.",
+		"Successfully set the preference. From now on, I'll show you the times on the 24-hour clock, e.g. 17:30.":                                                                            "Pomyślnie ustawiono preferencję. Od teraz będę ci pokazywać godziny w zegarze 24-godzinnym, np. 17:30.",
+		"Successfully set the preference. From now on, I'll show you the times on the 12-hour clock, e.g. 05:30 PM.":                                                                         "Pomyślnie ustawiono preferencję. Od teraz będę ci pokazywać godziny w zegarze 12-godzinnym, np. 05:30 PM.",
+		"Successfully set the preference. From now on, I'll remind you in your own words.":                                                                                                   "Pomyślnie ustawiono preferencję. Od teraz będę ci przypominać twoimi własnymi słowami.",
+		"Successfully set the preference. From now on, I'll turn e.g. \"my\" into \"your\" when reminding you.":                                                                              "Pomyślnie ustawiono preferencję. Od teraz przy przypominaniu będę zamieniać np. \"mój\" na \"twój\".",
+		"Successfully set the preference. From now on, only you can set reminders for yourself. The ones already set by others are listed in `!reminders`, in case you want to remove them.": "Pomyślnie ustawiono preferencję. Od teraz tylko ty możesz ustawiać sobie przypomnienia. Te ustawione już przez innych są na liście `!reminders`, jeśli chcesz je usunąć.",
+		"Successfully set the preference. From now on, anyone can set reminders for you with `!remind`.":                                                                                     "Pomyślnie ustawiono preferencję. Od teraz każdy może ustawiać ci przypomnienia przez `!remind`.",
+		// The reply to `!language pl` is already looked up in Polish.
+		"Successfully set the preference. From now on, I'll talk to you in English.": "Pomyślnie ustawiono preferencję. Od teraz będę z tobą rozmawiać po polsku.",
+
+		// The reminders.
+		"remove": "usuwać",
+		"edit":   "edytować",
+		"The ID is too big, has to be between 0 and %d.":                                                         "To ID jest za duże, musi być między 0 a %d.",
+		"There isn't a reminder with that ID. Make sure you provided the correct one.":                           "Nie ma przypomnienia o tym ID. Upewnij się, że podajesz właściwe.",
+		"You cannot %s someone else's reminders!":                                                                "Nie możesz %s cudzych przypomnień!",
+		"Only the one who set the reminder can change its text. You can still remove it with `!rmreminder`.":     "Tylko ten, kto ustawił przypomnienie, może zmienić jego treść. Nadal możesz je usunąć przez `!rmreminder`.",
+		"Successfully deleted the reminder.":                                                                     "Pomyślnie usunięto przypomnienie.",
+		"Successfully added to the database. I'll remind you %s %s.":                                             "Pomyślnie dodano do bazy. Przypomnę ci %s %s.",
+		"Successfully edited the reminder. I'll remind you %s instead.":                                          "Pomyślnie zmieniono przypomnienie. Zamiast tego przypomnę ci %s.",
+		"Successfully moved the reminder to the %s timezone, next time on %s.":                                   "Pomyślnie przeniesiono przypomnienie do strefy czasowej %s, następnym razem %s.",
+		"This reminder was already delivered, set a new time for it with `!editreminder <ID> time ...` instead.": "To przypomnienie zostało już dostarczone, zamiast tego ustaw mu nowy czas przez `!editreminder <ID> time ...`.",
+		"In this timezone the reminder would be in the past, who would've guessed?":                              "W tej strefie czasowej przypomnienie byłoby w przeszłości, kto by pomyślał?",
+		"The maximum reminder length is 1500 characters, you naughty person.":                                    "Przypomnienie może mieć najwyżej 1500 znaków, ty niegrzeczna osobo.",
+		"Immediately reminding you %s, you silly goose.":                                                         "Przypominam ci od razu %s, ty głuptasie.",
+		"This reminder doesn't exist anymore.":                                                                   "To przypomnienie już nie istnieje.",
+		"Only the owner of the reminder can use these buttons!":                                                  "Tylko właściciel przypomnienia może używać tych przycisków!",
+		"This button is broken, use `!editreminder` or `!rmreminder` instead.":                                   "Ten przycisk jest zepsuty, użyj zamiast niego `!editreminder` albo `!rmreminder`.",
+		"Snoozed until %s.": "Odłożono do %s.",
+		"You can only remind others on a server, you silly goose.":                                                                "Innym możesz przypominać tylko na serwerze, ty głuptasie.",
+		"Only the members with the roles set with `!config pingroles` and the server managers can remind roles or everyone here!": "Tylko członkowie z rolami ustawionymi przez `!config pingroles` i zarządcy serwera mogą przypominać rolom albo wszystkim tutaj!",
+		"Sorry, %s doesn't want to be reminded by others.":                                                                        "Wybacz, %s nie pozwala innym ustawiać sobie przypomnień.",
+		"Successfully added to the database. I'll remind %s %s %s.":                                                               "Pomyślnie dodano do bazy. Przypomnę %s %s %s.",
+		"Done!": "Zrobione!",
+
+		// The deliveries.
+		"%s, reminding you %s.":                                                       "%s, przypominam ci %s.",
+		"%s, reminding you %s (delivered %s late).":                                   "%s, przypominam ci %s (z opóźnieniem %s).",
+		"%s, %s asked me to remind you %s.":                                           "%s, na prośbę %s przypominam ci %s.",
+		"%s, %s asked me to remind you %s (delivered %s late).":                       "%s, na prośbę %s przypominam ci %s (z opóźnieniem %s).",
+		"%s, couldn't update the recurring reminder. You might need to set it again.": "%s, nie udało się zaktualizować powtarzającego się przypomnienia. Może trzeba je ustawić ponownie.",
+		"%s, I was offline for a while and skipped these reminders of yours:":         "%s, przez jakiś czas byłem offline i pominąłem te twoje przypomnienia:",
+		"%s, I was offline for a while, so I'm late with reminding you:":              "%s, przez jakiś czas byłem offline, więc spóźniłem się z przypomnieniem:",
+		"- %s, due on %s (%s ago)":                                                    "- %s, na %s (%s temu)",
+		dmFallbackNote:                                                                "\n*Nie mogłem ci napisać prywatnie, więc przypominam tutaj. Zezwól na wiadomości prywatne z tego serwera albo zmień `!deliverypreference`.*",
+
+		// The time syntaxes.
+		"in %s":                                  "za %s",
+		"on %s, %s at %s in the %s timezone":     "%s, %s o %s w strefie czasowej %s",
+		"%s at %s in the %s timezone":            "%s o %s w strefie czasowej %s",
+		"%s, starting on %s":                     "%s, zaczynając od %s",
+		"%s in the %s timezone, next time on %s": "%s w strefie czasowej %s, następnym razem %s",
+		"I can't remember things for longer than %s, you'll have to set it closer to the date.":                                "Nie pamiętam rzeczy dłużej niż %s, musisz to ustawić bliżej terminu.",
+		"The time has to follow one of the `!remindme` syntaxes, e.g. `in 2 days`, `tomorrow at 9` or `every monday at 9 AM`.": "Czas musi pasować do jednej ze składni `!remindme`, np. `za 2 dni`, `jutro o 9` albo `every monday at 9 AM`.",
+		"Are you sure you understand the clock?": "Na pewno rozumiesz, jak działa zegar?",
+		"There aren't that many hours in a day!": "Doba nie ma tylu godzin!",
+		"The time has to follow the [12-hour clock](https://en.wikipedia.org/wiki/12-hour_clock) when you add AM or PM.": "Z AM lub PM czas musi być w [zegarze 12-godzinnym](https://pl.wikipedia.org/wiki/Zegar_12-godzinny).",
+		"No month has %d days you silly goose.":                 "Żaden miesiąc nie ma %d dni, ty głuptasie.",
+		"There is no 0th month my dear pumpkin.":                "Nie ma zerowego miesiąca, moja droga dyniu.",
+		"There aren't that many months!":                        "Nie ma tylu miesięcy!",
+		"There aren't %d days in this month.":                   "Ten miesiąc nie ma %d dni.",
+		"There aren't %d days in %s.":                           "Miesiąc %[2]s nie ma %[1]d dni.",
+		"The year has to be either %d or %d.":                   "Rok musi być albo %d, albo %d.",
+		"The date cannot be in the past, who would've guessed?": "Data nie może być w przeszłości, kto by pomyślał?",
+		"Couldn't resolve your location. Make sure you spelled it correctly or check the stderr output.": "Nie udało się ustalić twojej lokalizacji. Upewnij się, że jest poprawnie napisana, albo sprawdź wyjście stderr.",
+		"That's way too far in the future, who would've guessed?":                                        "To zdecydowanie za daleko w przyszłości, kto by pomyślał?",
+		"A fraction of a year is hard to tell, use months or weeks instead.":                             "Trudno powiedzieć, ile to ułamek roku, użyj miesięcy albo tygodni.",
+		"A fraction of a month is hard to tell, use weeks or days instead.":                              "Trudno powiedzieć, ile to ułamek miesiąca, użyj tygodni albo dni.",
+		rightNowMessage: "To przecież teraz, ty głuptasie.",
+		"Either the UTC offset or the timezone, not both, you silly goose.":                                                        "Albo przesunięcie względem UTC, albo strefa czasowa, nie oba naraz, ty głuptasie.",
+		"There's no such UTC offset, who would've guessed?":                                                                        "Nie ma takiego przesunięcia względem UTC, kto by pomyślał?",
+		"Today when? Add the time, e.g. `today at 5 PM`.":                                                                          "Dziś, ale o której? Dodaj godzinę, np. `dziś o 17`.",
+		"This time has already passed today, who would've guessed?":                                                                "Ta godzina już dziś minęła, kto by pomyślał?",
+		"This weekend is almost over, who would've guessed? Try `next weekend` instead.":                                           "Ten weekend prawie się skończył, kto by pomyślał? Spróbuj `w przyszły weekend`.",
+		"This date won't come again next year, add the year explicitly.":                                                           "Tej daty nie będzie w przyszłym roku, podaj rok wprost.",
+		"Every 0 what? You silly goose.":                                                                                           "Co 0 czego? Ty głuptasie.",
+		"It's either every weekday or not at all.":                                                                                 "Albo w każdy dzień roboczy, albo wcale.",
+		"Use `every %s weeks on %s` instead.":                                                                                      "Zamiast tego użyj `every %s weeks on %s`.",
+		"Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`.":                                         "Wybieranie dnia przez `on` działa tylko z tygodniami, np. `every 2 weeks on friday`.",
+		"Put the timezone after the expression instead of using `CRON_TZ`, e.g. `!remindme cron \"0 9 * * 1\" Europe/Berlin ...`.": "Zamiast `CRON_TZ` podaj strefę czasową po wyrażeniu, np. `!remindme cron \"0 9 * * 1\" Europe/Warsaw ...`.",
+		"That's not a valid cron expression: %s.":                                                                                  "To nie jest poprawne wyrażenie cron: %s.",
+		"This cron expression never fires, who would've guessed?":                                                                  "To wyrażenie cron nigdy się nie uruchomi, kto by pomyślał?",
+
+		// The configuration.
+		"There's nothing to configure in the DMs, you silly goose.":                         "W wiadomościach prywatnych nie ma czego konfigurować, ty głuptasie.",
+		"Only the members with the Manage Server permission can change the configuration!":  "Tylko członkowie z uprawnieniem Zarządzanie serwerem mogą zmieniać konfigurację!",
+		"I can't see the roles of the members here, so `!config %s` only works on Discord.": "Nie widzę tutaj ról członków, więc `!config %s` działa tylko na Discordzie.",
+		"The current configuration:\n":                                                      "Obecna konfiguracja:\n",
+		"- channel: <#%s>\n":                                                                "- kanał: <#%s>\n",
+		"- channel: not set, using <#%s>\n":                                                 "- kanał: nie ustawiono, używam <#%s>\n",
+		"- channel: not set, using the channel the reminder was set in\n":                   "- kanał: nie ustawiono, używam kanału, na którym ustawiono przypomnienie\n",
+		"- timezone: `%s`\n":                                                                "- strefa czasowa: `%s`\n",
+		"- timezone: not set, using `%s`\n":                                                 "- strefa czasowa: nie ustawiono, używam `%s`\n",
+		"- prefix: `%s`\n":                                                                  "- prefiks: `%s`\n",
+		"- roles: %s\n":                                                                     "- role: %s\n",
+		"- roles: not set, everyone can use the commands\n":                                 "- role: nie ustawiono, każdy może używać komend\n",
+		"- pingroles: %s":                                                                   "- role do wzmianek: %s",
+		"- pingroles: not set, only the server managers can remind roles or everyone here":  "- role do wzmianek: nie ustawiono, tylko zarządcy serwera mogą przypominać rolom albo wszystkim tutaj",
+		"Successfully reset the reminders channel.":                                         "Pomyślnie przywrócono domyślny kanał przypomnień.",
+		"The channel has to be a mention or an ID, e.g. `!config channel #reminders`.":      "Kanał musi być wzmianką albo ID, np. `!config channel #przypomnienia`.",
+		"I can't see this channel. Make sure it's on this server and I have access to it.":  "Nie widzę tego kanału. Upewnij się, że jest na tym serwerze i mam do niego dostęp.",
+		"Successfully set the reminders channel to <#%s>.":                                  "Pomyślnie ustawiono kanał przypomnień na <#%s>.",
+		"Successfully reset the default timezone to `%s`.":                                  "Pomyślnie przywrócono domyślną strefę czasową `%s`.",
+		"Successfully set the preference to the %s timezone.":                               "Pomyślnie ustawiono preferencję na strefę czasową %s.",
+		"I don't know the `%s` timezone. Did you mean %s?":                                  "Nie znam strefy czasowej `%s`. Czy chodziło ci o %s?",
+		"I don't know the `%s` timezone. Try the IANA identifier (e.g. `America/New_York`), the abbreviation (e.g. `CET`), the UTC offset (e.g. `+02:00`) or the city (e.g. `Tokyo`).": "Nie znam strefy czasowej `%s`. Spróbuj identyfikatora IANA (np. `Europe/Warsaw`), skrótu (np. `CET`), przesunięcia względem UTC (np. `+02:00`) albo miasta (np. `Warszawa`).",
+		"Only the whole-hour UTC offsets work as timezones, so `%s` doesn't. Try the city instead.":                                                                                    "Jako strefy czasowe działają tylko przesunięcia względem UTC o pełne godziny, więc `%s` nie zadziała. Spróbuj podać miasto.",
+		"Only the whole-hour UTC offsets work as timezones, so `%s` doesn't. Use the timezone instead, e.g. %s.":                                                                       "Jako strefy czasowe działają tylko przesunięcia względem UTC o pełne godziny, więc `%s` nie zadziała. Użyj strefy czasowej, np. %s.",
+		"Successfully set the default timezone to `%s`.":                                                                                                                               "Pomyślnie ustawiono domyślną strefę czasową na `%s`.",
+		"Successfully reset the prefix to `%s`.":                                                                                                                                       "Pomyślnie przywrócono prefiks `%s`.",
+		"The prefix has to be at most 5 characters long, without any spaces or backticks.":                                                                                             "Prefiks może mieć najwyżej 5 znaków, bez spacji i grawisów.",
+		"Successfully set the prefix to `%s`. The slash commands still work as usual.":                                                                                                 "Pomyślnie ustawiono prefiks na `%s`. Komendy z ukośnikiem działają jak wcześniej.",
+		"Successfully reset the roles, everyone can use the commands now.":                                                                                                             "Pomyślnie przywrócono role, teraz każdy może używać komend.",
+		"The roles have to be mentions or IDs separated with spaces, e.g. `!config roles @Members @Moderators`.":                                                                       "Role muszą być wzmiankami albo ID oddzielonymi spacjami, np. `!config roles @Członkowie @Moderatorzy`.",
+		"Successfully reset the roles, only the server managers can remind roles or everyone here now.":                                                                                "Pomyślnie przywrócono role, teraz tylko zarządcy serwera mogą przypominać rolom albo wszystkim tutaj.",
+		"The roles have to be mentions or IDs separated with spaces, e.g. `!config pingroles @Moderators`.":                                                                            "Role muszą być wzmiankami albo ID oddzielonymi spacjami, np. `!config pingroles @Moderatorzy`.",
+		"Successfully set the roles. From now on, their members and the server managers can remind roles or everyone here.":                                                            "Pomyślnie ustawiono role. Od teraz ich członkowie i zarządcy serwera mogą przypominać rolom albo wszystkim tutaj.",
+		"Successfully set the roles. From now on, only their members and the server managers can use the commands.":                                                                    "Pomyślnie ustawiono role. Od teraz komend mogą używać tylko ich członkowie i zarządcy serwera.",
+
+		// The errors.
+		"Something went wrong while updating the DB. Check the stderr output.":             "Coś poszło nie tak przy aktualizowaniu bazy. Sprawdź wyjście stderr.",
+		"Something went wrong while querying the reminder. Check the stderr output.":       "Coś poszło nie tak przy pobieraniu przypomnienia. Sprawdź wyjście stderr.",
+		"Something went wrong while deleting the reminder. Check the stderr output.":       "Coś poszło nie tak przy usuwaniu przypomnienia. Sprawdź wyjście stderr.",
+		"Something went wrong while updating the reminder. Check the stderr output.":       "Coś poszło nie tak przy aktualizowaniu przypomnienia. Sprawdź wyjście stderr.",
+		"Something went wrong while parsing the recurrence rule. Check the stderr output.": "Coś poszło nie tak przy odczytywaniu reguły powtarzania. Sprawdź wyjście stderr.",
+		"Something went wrong while parsing the time. Check the stderr output.":            "Coś poszło nie tak przy odczytywaniu czasu. Sprawdź wyjście stderr.",
+		"Something went wrong while inserting to the DB. Check the stderr output.":         "Coś poszło nie tak przy zapisywaniu do bazy. Sprawdź wyjście stderr.",
+	},
+}
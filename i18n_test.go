@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTranslateCommandName(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"!przypomnij jutro o 9 zadzwonić do mamy", "!remindme jutro o 9 zadzwonić do mamy"},
+		{"!przypomnienia", "!reminders"},
+		{"!usuń 42", "!rmreminder 42"},
+		{"!zmień 42 time jutro", "!editreminder 42 time jutro"},
+		{"!język pl", "!language pl"},
+		// Only the name of the command is translated.
+		{"!remindme jutro !przypomnij", "!remindme jutro !przypomnij"},
+		{"!przypomnij", "!remindme"},
+		{"!przypomnijcie jutro", "!przypomnijcie jutro"},
+		{"przypomnij jutro", "przypomnij jutro"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if translated := translateCommandName(test.content); translated != test.expected {
+			t.Errorf("translateCommandName(%q) = %q, expected %q", test.content, translated, test.expected)
+		}
+	}
+}
+
+func TestPolishPlural(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{0, "godzin"},
+		{1, "godzina"},
+		{2, "godziny"},
+		{4, "godziny"},
+		{5, "godzin"},
+		{11, "godzin"},
+		{12, "godzin"},
+		{14, "godzin"},
+		{21, "godzin"},
+		{22, "godziny"},
+		{24, "godziny"},
+		{25, "godzin"},
+		{101, "godzin"},
+		{112, "godzin"},
+		{122, "godziny"},
+	}
+
+	for _, test := range tests {
+		if plural := polishPlural(test.n, "godzina", "godziny", "godzin"); plural != test.expected {
+			t.Errorf("polishPlural(%d) = %q, expected %q", test.n, plural, test.expected)
+		}
+	}
+}
+
+func TestDescribeRecurrenceInPolish(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+	}{
+		{"1 hour", "co godzinę"},
+		{"2 hour", "co 2 godziny"},
+		{"5 hour", "co 5 godzin"},
+		{"12 hour", "co 12 godzin"},
+		{"22 hour", "co 22 godziny"},
+		{"1 day", "codziennie"},
+		{"3 day", "co 3 dni"},
+		{"1 day monday,tuesday,wednesday,thursday,friday", "w każdy dzień roboczy"},
+		{"1 week", "co tydzień"},
+		{"1 week wednesday", "w każdą środę"},
+		{"1 week sunday", "w każdą niedzielę"},
+		{"2 week friday", "co 2 tygodnie w piątek"},
+		{"5 week tuesday", "co 5 tygodni we wtorek"},
+		{"cron CRON_TZ=Europe/Warsaw 0 9 1 * *", "według harmonogramu cron `0 9 1 * *`"},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRecurrence(test.rule)
+		if err != nil {
+			t.Errorf("parseRecurrence(%q) failed: %v", test.rule, err)
+			continue
+		}
+
+		if described := languagePolish.describeRecurrence(rule); described != test.expected {
+			t.Errorf("describeRecurrence(%q) = %q, expected %q", test.rule, described, test.expected)
+		}
+		if described := languageEnglish.describeRecurrence(rule); described != rule.describe() {
+			t.Errorf("expected the English description of %q to be %q, got %q", test.rule, rule.describe(), described)
+		}
+	}
+}
+
+var formatVerbRegex = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+// Returns the arguments of the types the verbs of the English format expect, e.g. an int for `%d`.
+func formatArguments(t *testing.T, format string) []any {
+	t.Helper()
+
+	args := []any{}
+	for _, verb := range formatVerbRegex.FindAllStringSubmatch(format, -1) {
+		switch verb[1] {
+		case "%":
+		case "d":
+			args = append(args, 42)
+		case "s", "q", "v":
+			args = append(args, "text")
+		case "t":
+			args = append(args, true)
+		default:
+			t.Errorf("unexpected verb %q in %q", verb[0], format)
+		}
+	}
+
+	return args
+}
+
+// The translations have to keep the verbs of the original, or the arguments end up formatted as `%!d(string=...)`.
+func TestCatalogKeepsTheVerbs(t *testing.T) {
+	for lang, messages := range catalog {
+		for original, translated := range messages {
+			args := formatArguments(t, original)
+			if formatted := fmt.Sprintf(original, args...); strings.Contains(formatted, "%!") {
+				t.Errorf("the English %q doesn't fit its own arguments: %q", original, formatted)
+			}
+			if formatted := fmt.Sprintf(translated, args...); strings.Contains(formatted, "%!") {
+				t.Errorf("the %s translation of %q doesn't fit the arguments: %q", lang, original, formatted)
+			}
+		}
+	}
+}
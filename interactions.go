@@ -0,0 +1,821 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The bounds of the integer options, Discord expects pointers for the minimums.
+var (
+	minReminderId = 0.0
+	maxReminderId = float64(math.MaxUint32)
+	minAmount     = 0.0
+)
+
+// `/config` is only shown to the members who can use it, the `!config` handler checks it anyway.
+// Neither it nor `/remind` make sense in the DMs.
+var (
+	manageServerPermission int64 = discordgo.PermissionManageServer
+	configInDms                  = false
+	remindInDms                  = false
+)
+
+// The options shared by the `/remindme` subcommands.
+var (
+	timezoneOption = &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "timezone",
+		Description:  "E.g. America/New_York, CET, +02:00 or Tokyo. Defaults to your preference.",
+		Autocomplete: true,
+	}
+	textOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "text",
+		Description: "What to remind you about, e.g. to buy a gift for Aurora",
+		Required:    true,
+		MaxLength:   1500,
+	}
+	periodOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "period",
+		Description: "AM or PM",
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "AM", Value: "AM"},
+			{Name: "PM", Value: "PM"},
+		},
+	}
+)
+
+func withRequired(option *discordgo.ApplicationCommandOption) *discordgo.ApplicationCommandOption {
+	required := *option
+	required.Required = true
+	return &required
+}
+
+// Name of the message context-menu command, which is also how it's displayed in the menu.
+const remindAboutCommandName = "Remind me about this"
+
+// Prefix of the modal's custom ID, followed by the channel and the message the reminder is about.
+const remindAboutModalPrefix = "remindabout:"
+
+// The application commands mirroring the `!` prefix commands, plus the context-menu one.
+var applicationCommands = []*discordgo.ApplicationCommand{
+	{
+		Type: discordgo.MessageApplicationCommand,
+		Name: remindAboutCommandName,
+	},
+	{
+		Name:        "reminders",
+		Description: "List your pending reminders",
+	},
+	{
+		Name:        "rmreminder",
+		Description: "Remove one of your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "id",
+				Description: "ID of the reminder, as shown by /reminders",
+				Required:    true,
+				MinValue:    &minReminderId,
+				MaxValue:    maxReminderId,
+			},
+		},
+	},
+	{
+		Name:        "editreminder",
+		Description: "Change the text, time or timezone of one of your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "id",
+				Description: "ID of the reminder, as shown by /reminders",
+				Required:    true,
+				MinValue:    &minReminderId,
+				MaxValue:    maxReminderId,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "field",
+				Description: "What to change",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "text", Value: "text"},
+					{Name: "time", Value: "time"},
+					{Name: "tz", Value: "tz"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "value",
+				Description: "The new text, time in any /remindme syntax (e.g. in 2 days) or timezone",
+				Required:    true,
+				MaxLength:   1500,
+			},
+		},
+	},
+	{
+		Name:        "tzpreference",
+		Description: "Set the timezone used when a reminder doesn't specify one",
+		Options:     []*discordgo.ApplicationCommandOption{withRequired(timezoneOption)},
+	},
+	{
+		Name:        "deliverypreference",
+		Description: "Choose where your reminders are sent",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "where",
+				Description: "In the DMs, in the channel the reminder was set in, or in the reminders channel",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "dm", Value: string(deliverDm)},
+					{Name: "here", Value: string(deliverHere)},
+					{Name: "default", Value: string(deliverDefault)},
+				},
+			},
+		},
+	},
+	{
+		Name:        "clockpreference",
+		Description: "Choose how the times are shown to you",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "clock",
+				Description: "On the 12-hour clock (e.g. 05:30 PM) or the 24-hour one (e.g. 17:30)",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "12h", Value: string(clock12h)},
+					{Name: "24h", Value: string(clock24h)},
+				},
+			},
+		},
+	},
+	{
+		Name:        "pronounpreference",
+		Description: "Choose whether \"my\" becomes \"your\" in your reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "rewrite",
+				Description: "Rewrite the reminders in the second person, or keep them in your own words",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "on", Value: string(pronounsRewrite)},
+					{Name: "off", Value: string(pronounsKeep)},
+				},
+			},
+		},
+	},
+	{
+		Name:        "remindpreference",
+		Description: "Choose whether others can set reminders for you",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "who",
+				Description: "Who can set reminders for you with /remind",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "anyone", Value: string(remindByAnyone)},
+					{Name: "me", Value: string(remindByMe)},
+				},
+			},
+		},
+	},
+	{
+		Name:        "language",
+		Description: "Choose the language of the replies",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "language",
+				Description: "The language I talk to you in",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "English", Value: string(languageEnglish)},
+					{Name: "Polski", Value: string(languagePolish)},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "config",
+		Description:              "Configure the bot on this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &configInDms,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the current configuration",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Set the channel the reminders are sent in",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Leave empty to go back to the default",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "timezone",
+				Description: "Set the timezone used for the members without a preference",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "timezone",
+						Description:  "E.g. America/New_York, CET, +02:00 or Tokyo. Leave empty to go back to the default.",
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "prefix",
+				Description: "Set the prefix of the message commands",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "prefix",
+						Description: "e.g. ?, leave empty to go back to !",
+						MaxLength:   5,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "roles",
+				Description: "Only let the members with these roles use the commands",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "roles",
+						Description: "Role mentions separated with spaces, leave empty to let everyone in",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "pingroles",
+				Description: "Let the members with these roles remind other roles and everyone here",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "pingroles",
+						Description: "Role mentions separated with spaces, leave empty to only let the server managers",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:         "remind",
+		Description:  "Set a reminder for someone else, a role or everyone here",
+		DMPermission: &remindInDms,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "target",
+				Description: "The user or role mention, or here for everyone in the channel",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "when",
+				Description: "e.g. tomorrow at 9, every monday at 10 AM or in 15 minutes",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "What to remind them about, e.g. to buy a gift for Aurora",
+				Required:    true,
+				MaxLength:   1500,
+			},
+		},
+	},
+	{
+		Name:        "remindme",
+		Description: "Set a reminder",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "when",
+				Description: "Remind you at a time said in plain words, e.g. tomorrow at 9",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "when",
+						Description: "e.g. tomorrow at 9, next friday at noon, in 2h30m, on Dec 24 or end of day",
+						Required:    true,
+					},
+					textOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "in",
+				Description: "Remind you after some time, e.g. in 2 days",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "amount",
+						Description: "How many units to wait",
+						Required:    true,
+						MinValue:    &minAmount,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "unit",
+						Description: "The unit of the amount",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "seconds", Value: "seconds"},
+							{Name: "minutes", Value: "minutes"},
+							{Name: "hours", Value: "hours"},
+							{Name: "days", Value: "days"},
+							{Name: "weeks", Value: "weeks"},
+							{Name: "months", Value: "months"},
+							{Name: "years", Value: "years"},
+						},
+					},
+					textOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "on",
+				Description: "Remind you on a date, e.g. on 23.12 at 12 PM",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "date",
+						Description: "DD.MM or DD.MM.YYYY",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "HH or HH:MM, on the 12-hour clock with the period or the 24-hour one without it",
+						Required:    true,
+					},
+					periodOption,
+					textOption,
+					timezoneOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "every",
+				Description: "Remind you repeatedly, e.g. every weekday at 10 AM",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "rule",
+						Description: "e.g. day, monday, weekday, 2 weeks on friday or 3 hours",
+						Required:    true,
+					},
+					textOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "HH or HH:MM, on the 24-hour clock without the period. Defaults to the current time.",
+					},
+					periodOption,
+					timezoneOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "cron",
+				Description: "Remind you according to a cron expression, e.g. 0 9 1 * *",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "expression",
+						Description: "Standard cron expression with 5 fields",
+						Required:    true,
+					},
+					textOption,
+					timezoneOption,
+				},
+			},
+		},
+	},
+}
+
+func optionsByName(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, option := range options {
+		byName[option.Name] = option
+	}
+
+	return byName
+}
+
+func stringOption(options map[string]*discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	if option, ok := options[name]; ok {
+		return strings.TrimSpace(option.StringValue())
+	}
+
+	return ""
+}
+
+// Joins the non-empty parts with single spaces.
+func joinCommand(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if len(part) > 0 {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	return strings.Join(nonEmpty, " ")
+}
+
+// Translates the application command into the equivalent `!` prefix command.
+func commandFromApplicationCommand(data discordgo.ApplicationCommandInteractionData) (string, bool) {
+	options := optionsByName(data.Options)
+
+	switch data.Name {
+	case "reminders":
+		return "!reminders", true
+	case "rmreminder":
+		return fmt.Sprintf("!rmreminder %d", options["id"].IntValue()), true
+	case "editreminder":
+		return joinCommand(fmt.Sprintf("!editreminder %d", options["id"].IntValue()), stringOption(options, "field"), stringOption(options, "value")), true
+	case "deliverypreference":
+		return "!deliverypreference " + stringOption(options, "where"), true
+	case "config":
+		if len(data.Options) == 0 {
+			return "", false
+		}
+
+		subcommand := data.Options[0]
+		if subcommand.Name == "show" {
+			return "!config", true
+		}
+
+		options = optionsByName(subcommand.Options)
+		var value string
+		if channel, ok := options["channel"]; ok {
+			value = fmt.Sprintf("<#%s>", channel.Value)
+		} else {
+			value = stringOption(options, subcommand.Name)
+		}
+		if len(value) == 0 {
+			value = "none"
+		}
+
+		return joinCommand("!config", subcommand.Name, value), true
+	case "clockpreference":
+		return "!clockpreference " + stringOption(options, "clock"), true
+	case "pronounpreference":
+		return "!pronounpreference " + stringOption(options, "rewrite"), true
+	case "remindpreference":
+		return "!remindpreference " + stringOption(options, "who"), true
+	case "language":
+		return "!language " + stringOption(options, "language"), true
+	case "tzpreference":
+		return "!tzpreference " + stringOption(options, "timezone"), true
+	case "remind":
+		return joinCommand("!remind", stringOption(options, "target"), stringOption(options, "when"), stringOption(options, "text")), true
+	case "remindme":
+		if len(data.Options) == 0 {
+			return "", false
+		}
+
+		subcommand := data.Options[0]
+		options = optionsByName(subcommand.Options)
+		text := stringOption(options, "text")
+		// In parentheses, so that e.g. a city isn't taken for the start of the text.
+		timezone := stringOption(options, "timezone")
+		if len(timezone) > 0 {
+			timezone = "(" + timezone + ")"
+		}
+
+		switch subcommand.Name {
+		case "when":
+			return joinCommand("!remindme", stringOption(options, "when"), text), true
+		case "in":
+			return joinCommand("!remindme in", fmt.Sprint(options["amount"].IntValue()), stringOption(options, "unit"), text), true
+		case "on":
+			return joinCommand("!remindme on", stringOption(options, "date"), "at", stringOption(options, "time"), stringOption(options, "period"), timezone, text), true
+		case "every":
+			at := ""
+			if clock := stringOption(options, "time"); len(clock) > 0 {
+				at = joinCommand("at", clock, stringOption(options, "period"))
+			}
+			return joinCommand("!remindme every", stringOption(options, "rule"), at, timezone, text), true
+		case "cron":
+			return joinCommand("!remindme cron", fmt.Sprintf("%q", stringOption(options, "expression")), timezone, text), true
+		}
+	}
+
+	return "", false
+}
+
+// Suggests the timezone identifiers matching what the user typed so far.
+func handleTimezoneAutocomplete(session *discordgo.Session, interaction *discordgo.Interaction, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	query := ""
+	for _, option := range options {
+		if len(option.Options) > 0 {
+			handleTimezoneAutocomplete(session, interaction, option.Options)
+			return
+		}
+
+		if option.Focused {
+			query = option.StringValue()
+		}
+	}
+
+	// Discord doesn't accept more than 25 choices.
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, name := range searchTimezoneNames(query, 25) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+
+	err := session.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		log.Println("Error responding with the autocomplete choices:", err)
+	}
+}
+
+// Asks the user when to remind them about the message the context-menu command was used on.
+func handleRemindAboutCommand(session *discordgo.Session, interaction *discordgo.Interaction) {
+	data := interaction.ApplicationCommandData()
+
+	channelId := interaction.ChannelID
+	if data.Resolved != nil {
+		if message, ok := data.Resolved.Messages[data.TargetID]; ok {
+			channelId = message.ChannelID
+		}
+	}
+
+	err := session.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: remindAboutModalPrefix + channelId + ":" + data.TargetID,
+			Title:    "Remind me about this message",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "when",
+						Label:       "When?",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "in 2 days, tomorrow at 9, on 23.12 at 12 PM Europe/Warsaw",
+						Required:    true,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "text",
+						Label:       "What about? Leave empty to just link the message.",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "to look at this thread again",
+						MaxLength:   1500,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Println("Error responding with the modal:", err)
+	}
+}
+
+// Returns the values of the text inputs in the submitted modal, keyed by their custom IDs.
+func modalValues(components []discordgo.MessageComponent) map[string]string {
+	values := make(map[string]string)
+	for _, component := range components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		for _, rowComponent := range row.Components {
+			if input, ok := rowComponent.(*discordgo.TextInput); ok {
+				values[input.CustomID] = strings.TrimSpace(input.Value)
+			}
+		}
+	}
+
+	return values
+}
+
+func handleRemindAboutModal(cmd *incomingCommand, data discordgo.ModalSubmitInteractionData) {
+	channelAndMessage, ok := strings.CutPrefix(data.CustomID, remindAboutModalPrefix)
+	if !ok {
+		log.Println("Received an unknown modal:", data.CustomID)
+		return
+	}
+
+	channelId, messageId, _ := strings.Cut(channelAndMessage, ":")
+	cmd.about = &messageRef{guildId: cmd.guildId, channelId: channelId, messageId: messageId}
+
+	values := modalValues(data.Components)
+	text := values["text"]
+	if len(text) == 0 {
+		text = "about this message"
+	}
+
+	handleCommand(cmd, joinCommand("!remindme", values["when"], text))
+}
+
+// Prefix of the custom IDs of the buttons attached to the delivered reminders, followed by the action
+// and the ID of the reminder, e.g. `reminder:snooze10m:42`.
+const reminderButtonPrefix = "reminder:"
+
+func reminderButtons(id int64) []discordgo.MessageComponent {
+	button := func(label string, action string, style discordgo.ButtonStyle) discordgo.MessageComponent {
+		return discordgo.Button{Label: label, Style: style, CustomID: fmt.Sprintf("%s%s:%d", reminderButtonPrefix, action, id)}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("Snooze 10m", "snooze10m", discordgo.SecondaryButton),
+			button("Snooze 1h", "snooze1h", discordgo.SecondaryButton),
+			button("Tomorrow", "tomorrow", discordgo.SecondaryButton),
+			button("Done", "done", discordgo.SuccessButton),
+		}},
+	}
+}
+
+// Snoozes or acknowledges the delivered reminder. A snoozed one-time reminder gets rescheduled,
+// while for a recurring one a one-time copy gets inserted, so that the following occurrences stay intact.
+func handleReminderButton(session *discordgo.Session, interaction *discordgo.Interaction, customId string) {
+	note, ok := pressReminderButton(commandFromInteraction(session, interaction), customId)
+	if !ok {
+		return
+	}
+
+	// Replace the buttons with the note, so that the reminder can't be snoozed twice from the same message.
+	noComponents := []discordgo.MessageComponent{}
+	err := session.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("%s\n*%s*", interaction.Message.Content, note),
+			Components: noComponents,
+		},
+	})
+	if err != nil {
+		log.Println("Error updating the reminder message:", err)
+	}
+}
+
+// Applies the button's action to the reminder and returns the note for the reminder's message. Replies with
+// the reason and returns false if the button can't be used.
+func pressReminderButton(cmd *incomingCommand, customId string) (string, bool) {
+	actionAndId, _ := strings.CutPrefix(customId, reminderButtonPrefix)
+	action, idString, _ := strings.Cut(actionAndId, ":")
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		log.Println("Received a malformed button:", customId)
+		cmd.reply("This button is broken, use `!editreminder` or `!rmreminder` instead.")
+		return "", false
+	}
+
+	existing, err := store.getReminder(id)
+	if errors.Is(err, errReminderNotFound) {
+		cmd.reply("This reminder doesn't exist anymore.")
+		return "", false
+	} else if err != nil {
+		log.Println("Error querying the reminder:", err)
+		cmd.reply("Something went wrong while querying the reminder. Check the stderr output.")
+		return "", false
+	}
+
+	if cmd.author != existing.who {
+		cmd.reply("Only the owner of the reminder can use these buttons!")
+		return "", false
+	}
+
+	loadedLocation := existing.loadLocation()
+	currentTime := timeNow().In(loadedLocation)
+	var newTime time.Time
+	switch action {
+	case "snooze10m":
+		newTime = currentTime.Add(10 * time.Minute)
+	case "snooze1h":
+		newTime = currentTime.Add(time.Hour)
+	case "tomorrow":
+		newTime = currentTime.AddDate(0, 0, 1)
+	case "done":
+	default:
+		log.Println("Received a button with an unknown action:", customId)
+		cmd.reply("This button is broken, use `!editreminder` or `!rmreminder` instead.")
+		return "", false
+	}
+	newTime = newTime.UTC()
+
+	var note string
+	switch {
+	case action == "done" && len(existing.recurrence) > 0:
+		// Nothing to do, the following occurrences are already scheduled.
+		note = cmd.language().translate("Done!")
+	case action == "done":
+		err = store.deleteReminder(id)
+		if err != nil {
+			log.Println("Error deleting the row:", err)
+			cmd.reply("Something went wrong while deleting the reminder. Check the stderr output.")
+			return "", false
+		}
+		reminderScheduler.unschedule(id)
+		note = cmd.language().translate("Done!")
+	case len(existing.recurrence) > 0:
+		// Keep the snoozed copy tied to where the original reminder was set.
+		cmd.about = &messageRef{guildId: existing.guildId, channelId: existing.channelId, messageId: existing.messageId}
+
+		// The copy belongs to whoever snoozed it, but still pings the same role.
+		err = insertReminder(cmd, existing.who, existing.targetRole, existing.toRemind, reminderTime{targetTime: newTime, location: loadedLocation})
+		if err != nil {
+			log.Println("Error inserting into the database:", err)
+			cmd.reply("Something went wrong while inserting to the DB. Check the stderr output.")
+			return "", false
+		}
+		note = cmd.sprintf("Snoozed until %s.", cmd.timestamp(newTime, loadedLocation))
+	default:
+		err = store.updateReminderTime(id, newTime)
+		if err != nil {
+			log.Println("Error updating the row:", err)
+			cmd.reply("Something went wrong while updating the reminder. Check the stderr output.")
+			return "", false
+		}
+		reminderScheduler.schedule(id, newTime)
+		note = cmd.sprintf("Snoozed until %s.", cmd.timestamp(newTime, loadedLocation))
+	}
+
+	return note, true
+}
+
+// The user is only set in the DMs, in the guilds it's a part of the member.
+func interactionAuthor(interaction *discordgo.Interaction) *discordgo.User {
+	if interaction.Member != nil {
+		return interaction.Member.User
+	}
+
+	return interaction.User
+}
+
+func commandFromInteraction(session *discordgo.Session, interaction *discordgo.Interaction) *incomingCommand {
+	return &incomingCommand{
+		messenger: &discordMessenger{session: session, interaction: interaction},
+		author:    interactionAuthor(interaction).ID,
+		guildId:   interaction.GuildID,
+		channelId: interaction.ChannelID,
+		roles:     memberRoles(interaction.Member),
+	}
+}
+
+func interactionCreate(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	switch interaction.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		handleTimezoneAutocomplete(session, interaction.Interaction, interaction.ApplicationCommandData().Options)
+	case discordgo.InteractionMessageComponent:
+		if customId := interaction.MessageComponentData().CustomID; strings.HasPrefix(customId, reminderButtonPrefix) {
+			handleReminderButton(session, interaction.Interaction, customId)
+		}
+	case discordgo.InteractionModalSubmit:
+		handleRemindAboutModal(commandFromInteraction(session, interaction.Interaction), interaction.ModalSubmitData())
+	case discordgo.InteractionApplicationCommand:
+		if interaction.ApplicationCommandData().CommandType == discordgo.MessageApplicationCommand {
+			handleRemindAboutCommand(session, interaction.Interaction)
+			return
+		}
+
+		cmd := commandFromInteraction(session, interaction.Interaction)
+
+		content, ok := commandFromApplicationCommand(interaction.ApplicationCommandData())
+		if !ok {
+			log.Println("Received an unknown application command:", interaction.ApplicationCommandData().Name)
+			cmd.reply("I don't know this command, the bot might need to be restarted.")
+			return
+		}
+
+		handleCommand(cmd, content)
+	}
+}
+
+// Registers the application commands globally, replacing the previously registered ones.
+func registerApplicationCommands(session *discordgo.Session) error {
+	_, err := session.ApplicationCommandBulkOverwrite(session.State.User.ID, "", applicationCommands)
+	return err
+}
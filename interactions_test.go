@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPressReminderButtonSnoozesTheOneTimeReminders(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		action   string
+		now      time.Time
+		expected time.Time
+		note     string
+	}{
+		{
+			action:   "snooze10m",
+			now:      time.Date(2026, 10, 14, 9, 0, 0, 0, warsaw),
+			expected: time.Date(2026, 10, 14, 9, 10, 0, 0, warsaw),
+			note:     "Snoozed until 14.10.2026 09:10 AM CEST.",
+		},
+		{
+			action:   "snooze1h",
+			now:      time.Date(2026, 10, 14, 23, 30, 0, 0, warsaw),
+			expected: time.Date(2026, 10, 15, 0, 30, 0, 0, warsaw),
+			note:     "Snoozed until 15.10.2026 12:30 AM CEST.",
+		},
+		{
+			action:   "tomorrow",
+			now:      time.Date(2026, 10, 14, 9, 0, 0, 0, warsaw),
+			expected: time.Date(2026, 10, 15, 9, 0, 0, 0, warsaw),
+			note:     "Snoozed until 15.10.2026 09:00 AM CEST.",
+		},
+		{
+			// The clocks go back that night, so tomorrow is 25 hours away.
+			action:   "tomorrow",
+			now:      time.Date(2026, 10, 24, 9, 0, 0, 0, warsaw),
+			expected: time.Date(2026, 10, 25, 9, 0, 0, 0, warsaw),
+			note:     "Snoozed until 25.10.2026 09:00 AM CET.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s on %s", test.action, test.now.Format(dstTimeLayout)), func(t *testing.T) {
+			useMemoryStore(t)
+			useFixedClock(t, test.now)
+			messenger := newRecordingMessenger()
+
+			created := createTestReminder(t, reminder{who: "aurora", time: test.now, toRemind: "to call mom", location: warsaw.String(), delivered: true})
+			cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+			note, ok := pressReminderButton(cmd, fmt.Sprintf("%s%s:%d", reminderButtonPrefix, test.action, created.id))
+			if !ok {
+				t.Fatalf("The button was rejected with %q.", messenger.replies)
+			}
+			if note != test.note {
+				t.Errorf("Expected the note %q, got %q.", test.note, note)
+			}
+
+			snoozed, err := store.getReminder(created.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !snoozed.time.Equal(test.expected) || snoozed.delivered {
+				t.Errorf("Expected the reminder to be due at %s again, got %s (delivered: %t).", test.expected.Format(dstTimeLayout), snoozed.time.In(warsaw).Format(dstTimeLayout), snoozed.delivered)
+			}
+			if due, ok := reminderScheduler.nextDueTime(); !ok || !due.Equal(test.expected) {
+				t.Errorf("Expected the reminder to be scheduled at %s, got %s (%t).", test.expected.Format(dstTimeLayout), due.In(warsaw).Format(dstTimeLayout), ok)
+			}
+
+			replies, _, _ := messenger.take()
+			if len(replies) > 0 {
+				t.Errorf("Expected no replies, got %q.", replies)
+			}
+		})
+	}
+}
+
+func TestPressReminderButtonSnoozesACopyOfTheRecurringReminders(t *testing.T) {
+	useMemoryStore(t)
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	useFixedClock(t, now)
+	messenger := newRecordingMessenger()
+
+	recurring := createTestReminder(t, reminder{who: "aurora", targetRole: "moderators", time: now.Add(24 * time.Hour), toRemind: "to check the reports", recurrence: "1 day", messageId: "message"})
+	cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "elsewhere"}
+	note, ok := pressReminderButton(cmd, fmt.Sprintf("%ssnooze1h:%d", reminderButtonPrefix, recurring.id))
+	if !ok {
+		t.Fatalf("The button was rejected with %q.", messenger.replies)
+	}
+	if expected := "Snoozed until 14.10.2026 10:00 AM UTC."; note != expected {
+		t.Errorf("Expected the note %q, got %q.", expected, note)
+	}
+
+	reminders, err := store.listReminders("aurora", "guild")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 2 {
+		t.Fatalf("Expected the recurring reminder and its copy, got %d reminders.", len(reminders))
+	}
+
+	original, copied := reminders[1], reminders[0]
+	if original.id != recurring.id || !original.time.Equal(recurring.time) || original.recurrence != "1 day" {
+		t.Errorf("Expected the recurring reminder to stay as it was, got %+v.", original)
+	}
+	if !copied.time.Equal(now.Add(time.Hour)) || len(copied.recurrence) > 0 {
+		t.Errorf("Expected a one-time copy due in an hour, got %+v.", copied)
+	}
+	if copied.who != "aurora" || copied.targetRole != "moderators" || copied.toRemind != "to check the reports" {
+		t.Errorf("Expected the copy to remind the same role of the same thing, got %+v.", copied)
+	}
+	if copied.channelId != "channel" || copied.messageId != "message" {
+		t.Errorf("Expected the copy to be tied to the original message, got %s/%s.", copied.channelId, copied.messageId)
+	}
+}
+
+func TestPressReminderButtonMarksTheRemindersAsDone(t *testing.T) {
+	useMemoryStore(t)
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	useFixedClock(t, now)
+	messenger := newRecordingMessenger()
+	cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+
+	oneTime := createTestReminder(t, reminder{who: "aurora", time: now.Add(time.Hour), toRemind: "to call mom"})
+	reminderScheduler.schedule(oneTime.id, oneTime.time)
+	if note, ok := pressReminderButton(cmd, fmt.Sprintf("%sdone:%d", reminderButtonPrefix, oneTime.id)); !ok || note != "Done!" {
+		t.Errorf("Expected the one-time reminder to be done, got %q (%t).", note, ok)
+	}
+	if _, err := store.getReminder(oneTime.id); err != errReminderNotFound {
+		t.Errorf("Expected the one-time reminder to be deleted, got %v.", err)
+	}
+	if due, ok := reminderScheduler.nextDueTime(); ok {
+		t.Errorf("Expected the one-time reminder to be unscheduled, it's still due at %s.", due)
+	}
+
+	recurring := createTestReminder(t, reminder{who: "aurora", time: now.Add(24 * time.Hour), toRemind: "to stretch", recurrence: "1 day"})
+	if note, ok := pressReminderButton(cmd, fmt.Sprintf("%sdone:%d", reminderButtonPrefix, recurring.id)); !ok || note != "Done!" {
+		t.Errorf("Expected the recurring reminder to be done, got %q (%t).", note, ok)
+	}
+	if kept, err := store.getReminder(recurring.id); err != nil || !kept.time.Equal(recurring.time) {
+		t.Errorf("Expected the recurring reminder to stay as it was, got %+v (%v).", kept, err)
+	}
+
+	replies, _, _ := messenger.take()
+	if len(replies) > 0 {
+		t.Errorf("Expected no replies, got %q.", replies)
+	}
+}
+
+func TestPressReminderButtonRejectsTheWrongPresses(t *testing.T) {
+	useMemoryStore(t)
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	useFixedClock(t, now)
+	messenger := newRecordingMessenger()
+
+	created := createTestReminder(t, reminder{who: "aurora", creator: "bruno", time: now.Add(time.Hour), toRemind: "to call mom"})
+	tests := []struct {
+		name     string
+		author   string
+		customId string
+		reply    string
+	}{
+		{
+			name:     "someone else",
+			author:   "bruno",
+			customId: fmt.Sprintf("%ssnooze10m:%d", reminderButtonPrefix, created.id),
+			reply:    "Only the owner of the reminder can use these buttons!",
+		},
+		{
+			name:     "someone else marking it as done",
+			author:   "bruno",
+			customId: fmt.Sprintf("%sdone:%d", reminderButtonPrefix, created.id),
+			reply:    "Only the owner of the reminder can use these buttons!",
+		},
+		{
+			name:     "a missing reminder",
+			author:   "aurora",
+			customId: fmt.Sprintf("%ssnooze10m:%d", reminderButtonPrefix, created.id+1),
+			reply:    "This reminder doesn't exist anymore.",
+		},
+		{
+			name:     "a malformed id",
+			author:   "aurora",
+			customId: reminderButtonPrefix + "snooze10m:soon",
+			reply:    "This button is broken, use `!editreminder` or `!rmreminder` instead.",
+		},
+		{
+			name:     "a missing id",
+			author:   "aurora",
+			customId: reminderButtonPrefix + "snooze10m",
+			reply:    "This button is broken, use `!editreminder` or `!rmreminder` instead.",
+		},
+		{
+			name:     "an unknown action",
+			author:   "aurora",
+			customId: fmt.Sprintf("%snextweek:%d", reminderButtonPrefix, created.id),
+			reply:    "This button is broken, use `!editreminder` or `!rmreminder` instead.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &incomingCommand{messenger: messenger, author: test.author, guildId: "guild", channelId: "channel"}
+			if note, ok := pressReminderButton(cmd, test.customId); ok {
+				t.Errorf("Expected the button to be rejected, got the note %q.", note)
+			}
+
+			replies, _, _ := messenger.take()
+			if len(replies) != 1 || replies[0] != test.reply {
+				t.Errorf("Expected the reply %q, got %q.", test.reply, replies)
+			}
+
+			unchanged, err := store.getReminder(created.id)
+			if err != nil || !unchanged.time.Equal(created.time) {
+				t.Errorf("Expected the reminder to stay as it was, got %+v (%v).", unchanged, err)
+			}
+		})
+	}
+}
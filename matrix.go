@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Talks to a Matrix homeserver through the client-server API, receiving the commands with the `/sync` long polling.
+// Every room plays the role of both the guild and the channel. For the commands, it also keeps what to reply to.
+type matrixMessenger struct {
+	homeserver string
+	token      string
+	client     *http.Client
+	userId     string
+	// The DM rooms by user, read from the `m.direct` account data.
+	directRooms   map[string]string
+	directRoomsMu *sync.Mutex
+
+	// Set for the commands, the replies are sent in the room as replies to the event.
+	roomId  string
+	eventId string
+}
+
+// Makes the transaction IDs of the sent messages unique, the homeserver deduplicates the retries with them.
+var matrixTransactions atomic.Int64
+
+// Checks the access token and finds out whose it is.
+func newMatrixMessenger(homeserver string, token string) (*matrixMessenger, error) {
+	m := &matrixMessenger{
+		homeserver:    strings.TrimSuffix(homeserver, "/"),
+		token:         token,
+		client:        &http.Client{Timeout: time.Minute},
+		directRooms:   make(map[string]string),
+		directRoomsMu: &sync.Mutex{},
+	}
+
+	var whoami struct {
+		UserId string `json:"user_id"`
+	}
+	if err := m.call(http.MethodGet, "/account/whoami", nil, &whoami); err != nil {
+		return nil, err
+	}
+	m.userId = whoami.UserId
+
+	return m, nil
+}
+
+// Calls the client-server API endpoint, relative to `/_matrix/client/v3`, and decodes the response into the result,
+// unless it's nil.
+func (m *matrixMessenger) call(method string, path string, body any, result any) error {
+	var requestBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequest(method, m.homeserver+"/_matrix/client/v3"+path, requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+m.token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var matrixError struct {
+			Errcode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		json.NewDecoder(response.Body).Decode(&matrixError)
+		return fmt.Errorf("%s %s: %s %s %s", method, path, response.Status, matrixError.Errcode, matrixError.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// Returns a copy of the messenger replying to the event.
+func (m *matrixMessenger) forCommand(roomId string, eventId string) *matrixMessenger {
+	replying := *m
+	replying.roomId = roomId
+	replying.eventId = eventId
+	return &replying
+}
+
+func (m *matrixMessenger) platform() string {
+	return platformMatrix
+}
+
+// Sends the text message in the room. The mentioned users are turned into pills and notified, together with
+// the whole room if it's mentioned. Nobody else is, even if the text happens to contain their ID.
+func (m *matrixMessenger) send(roomId string, content string, mentions []string, mentionsRoom bool, inReplyTo string) error {
+	formatted := strings.ReplaceAll(html.EscapeString(content), "\n", "<br>")
+	for _, who := range mentions {
+		escaped := html.EscapeString(who)
+		formatted = strings.ReplaceAll(formatted, escaped, fmt.Sprintf(`<a href="https://matrix.to/#/%s">%s</a>`, escaped, escaped))
+	}
+
+	message := map[string]any{
+		"msgtype":        "m.text",
+		"body":           content,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+		"m.mentions":     map[string]any{"user_ids": append([]string{}, mentions...), "room": mentionsRoom},
+	}
+	if len(inReplyTo) > 0 {
+		message["m.relates_to"] = map[string]any{"m.in_reply_to": map[string]string{"event_id": inReplyTo}}
+	}
+
+	transactionId := fmt.Sprintf("gopnik-%d-%d", time.Now().UnixNano(), matrixTransactions.Add(1))
+	path := fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(roomId), transactionId)
+	return m.call(http.MethodPut, path, message, nil)
+}
+
+func (m *matrixMessenger) reply(cmd *incomingCommand, msg string) {
+	if err := m.send(m.roomId, msg, nil, false, m.eventId); err != nil {
+		log.Println("Error replying to the Matrix message:", err)
+	}
+}
+
+func (m *matrixMessenger) sendToChannel(channelId string, message outgoingMessage) error {
+	// There are no snooze buttons on Matrix.
+	var inReplyTo string
+	if message.replyTo != nil {
+		inReplyTo = message.replyTo.messageId
+	}
+
+	return m.send(channelId, message.content, message.mentions, message.mentionedRole == roleHere, inReplyTo)
+}
+
+// Sends the message in the DM room with the user, creating one if there isn't any yet.
+func (m *matrixMessenger) sendDm(who string, message outgoingMessage) error {
+	roomId, err := m.directRoom(who)
+	if err != nil {
+		return err
+	}
+
+	message.replyTo = nil
+	return m.sendToChannel(roomId, message)
+}
+
+// The DM rooms are remembered in the `m.direct` account data, like the clients do, so that they survive restarts.
+func (m *matrixMessenger) directRoom(who string) (string, error) {
+	m.directRoomsMu.Lock()
+	defer m.directRoomsMu.Unlock()
+
+	if roomId, ok := m.directRooms[who]; ok {
+		return roomId, nil
+	}
+
+	accountDataPath := fmt.Sprintf("/user/%s/account_data/m.direct", url.PathEscape(m.userId))
+	direct := make(map[string][]string)
+	if err := m.call(http.MethodGet, accountDataPath, nil, &direct); err != nil {
+		// There's no account data before the first DM.
+		direct = make(map[string][]string)
+	}
+
+	if rooms := direct[who]; len(rooms) > 0 {
+		m.directRooms[who] = rooms[0]
+		return rooms[0], nil
+	}
+
+	var created struct {
+		RoomId string `json:"room_id"`
+	}
+	err := m.call(http.MethodPost, "/createRoom", map[string]any{
+		"is_direct": true,
+		"invite":    []string{who},
+		"preset":    "trusted_private_chat",
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+
+	direct[who] = append(direct[who], created.RoomId)
+	if err = m.call(http.MethodPut, accountDataPath, direct, nil); err != nil {
+		log.Println("Error saving the DM room:", err)
+	}
+
+	m.directRooms[who] = created.RoomId
+	return created.RoomId, nil
+}
+
+func (m *matrixMessenger) mention(who string) string {
+	// The pill is added when sending, with the mentions of the message.
+	return who
+}
+
+func (m *matrixMessenger) mentionRole(role string) string {
+	// There are no roles on Matrix, only the whole room can be mentioned.
+	return "@room"
+}
+
+func (m *matrixMessenger) escapeMentions(text string) string {
+	// The messages say whom they mention in `m.mentions`, the text doesn't notify anyone.
+	return text
+}
+
+var matrixUserIdRegex = regexp.MustCompile(`^@[^:\s]+:\S+$`)
+
+func (m *matrixMessenger) parseMention(mention string) (string, bool, bool) {
+	if matrixUserIdRegex.MatchString(mention) {
+		return mention, false, true
+	}
+
+	return "", false, false
+}
+
+func (m *matrixMessenger) timestamp(t time.Time, location *time.Location, clock clockPreference) string {
+	// The clients can't format the dates for their users.
+	local := t.In(location)
+	return fmt.Sprintf("%s %s %s", local.Format("02.01.2006"), clock.format(local), local.Format("MST"))
+}
+
+func (m *matrixMessenger) messageLink(message messageRef) string {
+	return fmt.Sprintf("https://matrix.to/#/%s/%s", url.PathEscape(message.channelId), url.PathEscape(message.messageId))
+}
+
+// The members who can change the room's power levels can also configure the bot in the room.
+func (m *matrixMessenger) canManageGuild(cmd *incomingCommand) bool {
+	var powerLevels struct {
+		Users        map[string]int `json:"users"`
+		UsersDefault int            `json:"users_default"`
+		Events       map[string]int `json:"events"`
+		StateDefault *int           `json:"state_default"`
+	}
+	path := fmt.Sprintf("/rooms/%s/state/m.room.power_levels", url.PathEscape(cmd.guildId))
+	if err := m.call(http.MethodGet, path, nil, &powerLevels); err != nil {
+		log.Println("Error checking the permissions:", err)
+		return false
+	}
+
+	required := 50
+	if level, ok := powerLevels.Events["m.room.power_levels"]; ok {
+		required = level
+	} else if powerLevels.StateDefault != nil {
+		required = *powerLevels.StateDefault
+	}
+
+	level, ok := powerLevels.Users[cmd.author]
+	if !ok {
+		level = powerLevels.UsersDefault
+	}
+
+	return level >= required
+}
+
+func (m *matrixMessenger) channelGuild(channelId string) (string, error) {
+	// Every room is its own guild, so the reminders channel can only be the room itself.
+	return channelId, nil
+}
+
+type matrixEvent struct {
+	Type    string `json:"type"`
+	Sender  string `json:"sender"`
+	EventId string `json:"event_id"`
+	Content struct {
+		Msgtype   string `json:"msgtype"`
+		Body      string `json:"body"`
+		RelatesTo struct {
+			RelType   string `json:"rel_type"`
+			InReplyTo *struct {
+				EventId string `json:"event_id"`
+			} `json:"m.in_reply_to"`
+		} `json:"m.relates_to"`
+	} `json:"content"`
+}
+
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+		Invite map[string]json.RawMessage `json:"invite"`
+	} `json:"rooms"`
+}
+
+// Removes the quote of the replied-to message the clients put before the reply, e.g.
+// "> <@aurora:matrix.org> Let's meet tomorrow\n\n!remindme tomorrow at 9 to meet Aurora".
+func stripReplyFallback(body string) string {
+	lines := strings.Split(body, "\n")
+	quoted := 0
+	for quoted < len(lines) && strings.HasPrefix(lines[quoted], ">") {
+		quoted++
+	}
+	if quoted == 0 {
+		return body
+	}
+
+	if quoted < len(lines) && len(lines[quoted]) == 0 {
+		quoted++
+	}
+
+	return strings.Join(lines[quoted:], "\n")
+}
+
+func (m *matrixMessenger) handleEvent(roomId string, event matrixEvent) {
+	// Ignore the bot's own messages, the edits and anything that isn't text.
+	if event.Type != "m.room.message" || event.Sender == m.userId || event.Content.Msgtype != "m.text" || event.Content.RelatesTo.RelType == "m.replace" {
+		return
+	}
+
+	body := event.Content.Body
+	if event.Content.RelatesTo.InReplyTo != nil {
+		body = stripReplyFallback(body)
+	}
+
+	content, ok := strings.CutPrefix(body, loadGuildSettings(roomId).prefix)
+	if !ok {
+		return
+	}
+
+	cmd := incomingCommand{
+		messenger: m.forCommand(roomId, event.EventId),
+		author:    event.Sender,
+		guildId:   roomId,
+		channelId: roomId,
+	}
+	handleCommand(&cmd, defaultPrefix+content)
+}
+
+// Keeps syncing with the homeserver until the stop channel is closed, joining the rooms the bot is invited to
+// and handling the commands sent in them. The messages sent before the bot started are skipped.
+func (m *matrixMessenger) listen(stop chan struct{}) {
+	since := ""
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		query := url.Values{"timeout": {"30000"}}
+		if len(since) > 0 {
+			query.Set("since", since)
+		} else {
+			query.Set("timeout", "0")
+			query.Set("filter", `{"room":{"timeline":{"limit":1}}}`)
+		}
+
+		var response matrixSyncResponse
+		if err := m.call(http.MethodGet, "/sync?"+query.Encode(), nil, &response); err != nil {
+			log.Println("Error syncing with the Matrix homeserver:", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for roomId := range response.Rooms.Invite {
+			if err := m.call(http.MethodPost, fmt.Sprintf("/join/%s", url.PathEscape(roomId)), map[string]any{}, nil); err != nil {
+				log.Println("Error joining the Matrix room:", err)
+			}
+		}
+
+		if len(since) > 0 {
+			for roomId, room := range response.Rooms.Join {
+				for _, event := range room.Timeline.Events {
+					go m.handleEvent(roomId, event)
+				}
+			}
+		}
+
+		since = response.NextBatch
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testMatrixBot = "@gopnik:example.org"
+
+// A message the bot sent to a room.
+type matrixSent struct {
+	roomId  string
+	content map[string]any
+}
+
+// Stands in for a Matrix homeserver, with the responses to `/sync` given by the test.
+type fakeHomeserver struct {
+	server *httptest.Server
+	sent   chan matrixSent
+	joined chan string
+
+	mu sync.Mutex
+	// Returned one by one to the `/sync` calls, the last one repeatedly.
+	syncs       []string
+	sinces      []string
+	direct      map[string][]string
+	createdDms  []string
+	powerLevels map[string]string
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	t.Helper()
+
+	fake := &fakeHomeserver{
+		sent:        make(chan matrixSent, 16),
+		joined:      make(chan string, 16),
+		direct:      make(map[string][]string),
+		powerLevels: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_matrix/client/v3/account/whoami", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"user_id":%q}`, testMatrixBot)
+	})
+	mux.HandleFunc("GET /_matrix/client/v3/sync", func(writer http.ResponseWriter, request *http.Request) {
+		fake.mu.Lock()
+		fake.sinces = append(fake.sinces, request.URL.Query().Get("since"))
+		response := fake.syncs[0]
+		if len(fake.syncs) > 1 {
+			fake.syncs = fake.syncs[1:]
+		} else {
+			// Nothing new, like a long poll that timed out.
+			time.Sleep(10 * time.Millisecond)
+		}
+		fake.mu.Unlock()
+
+		writer.Write([]byte(response))
+	})
+	mux.HandleFunc("POST /_matrix/client/v3/join/{room}", func(writer http.ResponseWriter, request *http.Request) {
+		fake.joined <- request.PathValue("room")
+		writer.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/send/m.room.message/{transaction}", func(writer http.ResponseWriter, request *http.Request) {
+		var content map[string]any
+		if err := json.NewDecoder(request.Body).Decode(&content); err != nil {
+			t.Errorf("malformed message: %v", err)
+		}
+		fake.sent <- matrixSent{roomId: request.PathValue("room"), content: content}
+		writer.Write([]byte(`{"event_id":"$sent"}`))
+	})
+	mux.HandleFunc("GET /_matrix/client/v3/rooms/{room}/state/m.room.power_levels", func(writer http.ResponseWriter, request *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		powerLevels, ok := fake.powerLevels[request.PathValue("room")]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+			return
+		}
+		writer.Write([]byte(powerLevels))
+	})
+	accountData := "/_matrix/client/v3/user/" + testMatrixBot + "/account_data/m.direct"
+	mux.HandleFunc("GET "+accountData, func(writer http.ResponseWriter, request *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		if len(fake.direct) == 0 {
+			writer.WriteHeader(http.StatusNotFound)
+			writer.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Account data not found."}`))
+			return
+		}
+		json.NewEncoder(writer).Encode(fake.direct)
+	})
+	mux.HandleFunc("PUT "+accountData, func(writer http.ResponseWriter, request *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		fake.direct = make(map[string][]string)
+		if err := json.NewDecoder(request.Body).Decode(&fake.direct); err != nil {
+			t.Errorf("malformed account data: %v", err)
+		}
+		writer.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("POST /_matrix/client/v3/createRoom", func(writer http.ResponseWriter, request *http.Request) {
+		var options struct {
+			IsDirect bool     `json:"is_direct"`
+			Invite   []string `json:"invite"`
+		}
+		json.NewDecoder(request.Body).Decode(&options)
+		if !options.IsDirect || len(options.Invite) != 1 {
+			t.Errorf("expected a DM room with a single user, got %+v", options)
+		}
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		fake.createdDms = append(fake.createdDms, options.Invite...)
+		fmt.Fprintf(writer, `{"room_id":"!dm%d:example.org"}`, len(fake.createdDms))
+	})
+
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+
+	return fake
+}
+
+func (fake *fakeHomeserver) connect(t *testing.T) *matrixMessenger {
+	t.Helper()
+
+	m, err := newMatrixMessenger(fake.server.URL+"/", "syt_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.userId != testMatrixBot {
+		t.Fatalf("expected the bot's user ID from whoami, got %q", m.userId)
+	}
+
+	return m
+}
+
+func (fake *fakeHomeserver) nextSent(t *testing.T) matrixSent {
+	t.Helper()
+
+	select {
+	case sent := <-fake.sent:
+		return sent
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was sent")
+		return matrixSent{}
+	}
+}
+
+func TestMatrixSync(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeHomeserver(t)
+	fake.syncs = []string{
+		// The initial sync, the commands sent before the bot started are skipped.
+		`{"next_batch":"s1","rooms":{
+			"invite":{"!new:example.org":{}},
+			"join":{"!room:example.org":{"timeline":{"events":[
+				{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$old","content":{"msgtype":"m.text","body":"!reminders"}}
+			]}}}
+		}}`,
+		`{"next_batch":"s2","rooms":{"join":{"!room:example.org":{"timeline":{"events":[
+			{"type":"m.room.message","sender":"@gopnik:example.org","event_id":"$own","content":{"msgtype":"m.text","body":"!reminders"}},
+			{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$edit","content":{"msgtype":"m.text","body":"* !reminders","m.relates_to":{"rel_type":"m.replace","event_id":"$old"}}},
+			{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$image","content":{"msgtype":"m.image","body":"!reminders"}},
+			{"type":"m.room.member","sender":"@aurora:example.org","event_id":"$member","content":{}},
+			{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$new","content":{"msgtype":"m.text","body":"!reminders"}}
+		]}}}}}`,
+		`{"next_batch":"s3"}`,
+	}
+	m := fake.connect(t)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		m.listen(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	select {
+	case roomId := <-fake.joined:
+		if roomId != "!new:example.org" {
+			t.Errorf("expected to join the room the bot was invited to, got %q", roomId)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the invite wasn't accepted")
+	}
+
+	sent := fake.nextSent(t)
+	relatesTo, _ := sent.content["m.relates_to"].(map[string]any)
+	inReplyTo, _ := relatesTo["m.in_reply_to"].(map[string]any)
+	if sent.roomId != "!room:example.org" || sent.content["body"] != "You have no pending reminders." || inReplyTo["event_id"] != "$new" {
+		t.Errorf("expected a reply to the new command, got %+v", sent)
+	}
+
+	select {
+	case sent := <-fake.sent:
+		t.Errorf("expected the other events to be ignored, got %+v", sent)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.sinces) < 3 || fake.sinces[0] != "" || fake.sinces[1] != "s1" || fake.sinces[2] != "s2" {
+		t.Errorf("expected the syncs to continue from the previous batches, got %v", fake.sinces)
+	}
+}
+
+func parseMatrixEvent(t *testing.T, event string) matrixEvent {
+	t.Helper()
+
+	var parsed matrixEvent
+	if err := json.Unmarshal([]byte(event), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func TestMatrixReplies(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeHomeserver(t)
+	m := fake.connect(t)
+
+	// Outside of the replies, the quote is a part of the message.
+	m.handleEvent("!room:example.org", parseMatrixEvent(t, `{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$quote","content":{
+		"msgtype":"m.text",
+		"body":"> <@bruno:example.org> Don't forget the cake\n\n!reminders"
+	}}`))
+	if len(fake.sent) > 0 {
+		t.Fatalf("expected the quote not to be stripped, got %+v", <-fake.sent)
+	}
+
+	m.handleEvent("!room:example.org", parseMatrixEvent(t, `{"type":"m.room.message","sender":"@aurora:example.org","event_id":"$reply","content":{
+		"msgtype":"m.text",
+		"body":"> <@bruno:example.org> Don't forget the cake\n> for the party\n\n!reminders",
+		"m.relates_to":{"m.in_reply_to":{"event_id":"$cake"}}
+	}}`))
+	if sent := fake.nextSent(t); sent.content["body"] != "You have no pending reminders." {
+		t.Errorf("expected the command in the reply to be handled, got %+v", sent)
+	}
+}
+
+func TestStripReplyFallback(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"> <@bruno:example.org> hi\n\n!reminders", "!reminders"},
+		{"> <@bruno:example.org> hi\n> there\n\n!remindme in 2h\nto reply", "!remindme in 2h\nto reply"},
+		// Without the blank line, which some clients leave out.
+		{"> <@bruno:example.org> hi\n!reminders", "!reminders"},
+		// The clients that don't send the fallback.
+		{"!reminders", "!reminders"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if stripped := stripReplyFallback(test.body); stripped != test.expected {
+			t.Errorf("stripReplyFallback(%q) = %q, expected %q", test.body, stripped, test.expected)
+		}
+	}
+}
+
+func TestMatrixDirectRooms(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeHomeserver(t)
+	fake.direct["@aurora:example.org"] = []string{"!aurora:example.org", "!older:example.org"}
+	m := fake.connect(t)
+
+	message := outgoingMessage{content: "@aurora:example.org, reminding you to buy milk.", mentions: []string{"@aurora:example.org"}}
+	if err := m.sendDm("@aurora:example.org", message); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.nextSent(t)
+	if sent.roomId != "!aurora:example.org" {
+		t.Errorf("expected the DM room from m.direct to be reused, got %q", sent.roomId)
+	}
+	mentions, _ := sent.content["m.mentions"].(map[string]any)
+	if userIds, _ := mentions["user_ids"].([]any); len(userIds) != 1 || userIds[0] != "@aurora:example.org" || mentions["room"] != false {
+		t.Errorf("expected only aurora to be mentioned, got %v", mentions)
+	}
+	if formatted, _ := sent.content["formatted_body"].(string); !strings.Contains(formatted, `<a href="https://matrix.to/#/@aurora:example.org">`) {
+		t.Errorf("expected a pill for aurora, got %q", formatted)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := m.sendDm("@bruno:example.org", outgoingMessage{content: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+		if sent := fake.nextSent(t); sent.roomId != "!dm1:example.org" {
+			t.Errorf("expected the created DM room, got %q", sent.roomId)
+		}
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.createdDms) != 1 {
+		t.Errorf("expected a single DM room to be created, got %v", fake.createdDms)
+	}
+	if rooms := fake.direct["@bruno:example.org"]; len(rooms) != 1 || rooms[0] != "!dm1:example.org" {
+		t.Errorf("expected the created DM room to be saved in m.direct, got %v", fake.direct)
+	}
+	if rooms := fake.direct["@aurora:example.org"]; len(rooms) != 2 {
+		t.Errorf("expected the other DM rooms to stay in m.direct, got %v", fake.direct)
+	}
+}
+
+func TestMatrixCanManageGuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		powerLevels string
+		author      string
+		expected    bool
+	}{
+		{"default level", `{"users":{"@admin:example.org":100}}`, "@admin:example.org", true},
+		{"below the default", `{"users":{"@mod:example.org":49}}`, "@mod:example.org", false},
+		{"users default", `{"users_default":50}`, "@aurora:example.org", true},
+		{"power levels event", `{"users":{"@mod:example.org":50},"events":{"m.room.power_levels":100}}`, "@mod:example.org", false},
+		{"state default", `{"users_default":10,"state_default":10}`, "@aurora:example.org", true},
+		{"power levels event over the state default", `{"state_default":0,"events":{"m.room.power_levels":50}}`, "@aurora:example.org", false},
+		{"no power levels", "", "@aurora:example.org", false},
+	}
+
+	fake := newFakeHomeserver(t)
+	m := fake.connect(t)
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			roomId := fmt.Sprintf("!room%d:example.org", i)
+			if len(test.powerLevels) > 0 {
+				fake.mu.Lock()
+				fake.powerLevels[roomId] = test.powerLevels
+				fake.mu.Unlock()
+			}
+
+			cmd := incomingCommand{messenger: m, author: test.author, guildId: roomId, channelId: roomId}
+			if allowed := m.canManageGuild(&cmd); allowed != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, allowed)
+			}
+		})
+	}
+}
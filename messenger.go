@@ -0,0 +1,100 @@
+package main
+
+import "time"
+
+// The chat platforms the bot can run on, as stored with the reminders.
+const (
+	platformDiscord = "discord"
+	platformSlack   = "slack"
+	platformMatrix  = "matrix"
+)
+
+// What the reminders need from a chat platform. The handlers and the delivery only talk to the platform
+// through it, so that the same logic can serve several of them.
+type Messenger interface {
+	platform() string
+	// Answers the command wherever the platform answers them, e.g. ephemerally for the slash commands.
+	reply(cmd *incomingCommand, msg string)
+	sendToChannel(channelId string, message outgoingMessage) error
+	sendDm(who string, message outgoingMessage) error
+	// Formats the mention of the user, e.g. `<@42>` on Discord.
+	mention(who string) string
+	// Formats the mention of the role, or of everyone in the channel for roleHere.
+	mentionRole(role string) string
+	// Defuses the mentions in the user's text which would notify more than the message is meant to, e.g. a typed
+	// `@everyone` in a reminder for `@here` on Discord.
+	escapeMentions(text string) string
+	// Returns the ID of the user or the role mentioned in a command, and whether it's a role.
+	// Returns false if it isn't a mention.
+	parseMention(mention string) (string, bool, bool)
+	// Formats the time in the location, with the time of day on the clock. The platforms which can also show it
+	// in every user's own timezone do so next to it.
+	timestamp(t time.Time, location *time.Location, clock clockPreference) string
+	messageLink(message messageRef) string
+	// Whether the author can change the configuration of the guild the command was used in.
+	canManageGuild(cmd *incomingCommand) bool
+	// Returns the guild the channel belongs to, or an error if the bot can't see the channel.
+	channelGuild(channelId string) (string, error)
+}
+
+// Points to a message on the platform. The guild is empty in the DMs.
+type messageRef struct {
+	guildId   string
+	channelId string
+	messageId string
+}
+
+// A command in the `!` prefix syntax, whichever platform and input path it came from.
+type incomingCommand struct {
+	messenger Messenger
+	author    string
+	// Where the command was used. The guild is empty in the DMs.
+	guildId   string
+	channelId string
+	// The roles of the author in the guild, checked against the ones set with `!config roles`.
+	roles []string
+	// Set when the reminder is about a specific message, e.g. when it's created from the context menu.
+	about *messageRef
+}
+
+// Replies in the author's language. The messages missing from the catalog are sent as they are.
+func (cmd *incomingCommand) reply(msg string) {
+	cmd.messenger.reply(cmd, cmd.language().translate(msg))
+}
+
+func (cmd *incomingCommand) language() language {
+	return loadLanguage(cmd.author)
+}
+
+// Formats the message in the author's language.
+func (cmd *incomingCommand) sprintf(format string, args ...any) string {
+	return cmd.language().sprintf(format, args...)
+}
+
+// Formats the time in the location for the author, following their clock preference.
+func (cmd *incomingCommand) timestamp(t time.Time, location *time.Location) string {
+	return cmd.messenger.timestamp(t, location, loadClockPreference(cmd.author))
+}
+
+// Returns the guild and the channel the command was used in, or the ones of the message it's about.
+func (cmd *incomingCommand) origin() (string, string) {
+	if cmd.about != nil {
+		return cmd.about.guildId, cmd.about.channelId
+	}
+
+	return cmd.guildId, cmd.channelId
+}
+
+// A message sent by the bot on its own, i.e. not as a reply to a command.
+type outgoingMessage struct {
+	content string
+	// Set for the delivered reminders, so that they get the snooze buttons.
+	reminderId int64
+	// The message to reply to, only set when it's in the same channel.
+	replyTo *messageRef
+	// The users mentioned in the content, for the platforms that need to be told explicitly whom to notify.
+	mentions []string
+	// The role mentioned in the content, or roleHere for everyone in the channel. Like the users, it has to be
+	// explicit for the platforms that can tell whom to notify.
+	mentionedRole string
+}
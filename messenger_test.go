@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A message the recordingMessenger was asked to send, to a channel or to a user's DMs.
+type sentMessage struct {
+	to      string
+	message outgoingMessage
+}
+
+// Stands in for a chat platform, formatting the mentions the way Discord does and keeping everything it was
+// asked to send.
+type recordingMessenger struct {
+	mu      sync.Mutex
+	replies []string
+	sent    []sentMessage
+	dms     []sentMessage
+	// The platform it pretends to be, Discord unless set.
+	platformName string
+	// The users who can change the configuration of every guild.
+	managers map[string]bool
+	// The users whose DMs are closed.
+	closedDms map[string]bool
+	// How many times the permissions were checked.
+	managerChecks int
+}
+
+func newRecordingMessenger() *recordingMessenger {
+	return &recordingMessenger{managers: make(map[string]bool), closedDms: make(map[string]bool)}
+}
+
+func (m *recordingMessenger) platform() string {
+	if len(m.platformName) > 0 {
+		return m.platformName
+	}
+
+	return platformDiscord
+}
+
+func (m *recordingMessenger) reply(cmd *incomingCommand, msg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.replies = append(m.replies, msg)
+}
+
+func (m *recordingMessenger) sendToChannel(channelId string, message outgoingMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, sentMessage{to: channelId, message: message})
+	return nil
+}
+
+func (m *recordingMessenger) sendDm(who string, message outgoingMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closedDms[who] {
+		return errors.New("cannot send messages to this user")
+	}
+
+	m.dms = append(m.dms, sentMessage{to: who, message: message})
+	return nil
+}
+
+func (m *recordingMessenger) mention(who string) string {
+	return fmt.Sprintf("<@%s>", who)
+}
+
+func (m *recordingMessenger) mentionRole(role string) string {
+	if role == roleHere {
+		return "@here"
+	}
+
+	return fmt.Sprintf("<@&%s>", role)
+}
+
+func (m *recordingMessenger) escapeMentions(text string) string {
+	return discordEveryoneRegex.ReplaceAllString(text, "@\u200b$1")
+}
+
+var recordedMentionRegex = regexp.MustCompile(`^<@(&?)(\w+)>$`)
+
+func (m *recordingMessenger) parseMention(mention string) (string, bool, bool) {
+	matches := recordedMentionRegex.FindStringSubmatch(mention)
+	if matches == nil {
+		return "", false, false
+	}
+
+	return matches[2], len(matches[1]) > 0, true
+}
+
+func (m *recordingMessenger) timestamp(t time.Time, location *time.Location, clock clockPreference) string {
+	local := t.In(location)
+	return fmt.Sprintf("%s %s %s", local.Format("02.01.2006"), clock.format(local), local.Format("MST"))
+}
+
+func (m *recordingMessenger) messageLink(message messageRef) string {
+	return fmt.Sprintf("https://chat.example/%s/%s/%s", message.guildId, message.channelId, message.messageId)
+}
+
+func (m *recordingMessenger) canManageGuild(cmd *incomingCommand) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.managerChecks++
+	return m.managers[cmd.author]
+}
+
+func (m *recordingMessenger) channelGuild(channelId string) (string, error) {
+	return "guild", nil
+}
+
+// Returns the replies and the messages sent so far and forgets them.
+func (m *recordingMessenger) take() ([]string, []sentMessage, []sentMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	replies, sent, dms := m.replies, m.sent, m.dms
+	m.replies, m.sent, m.dms = nil, nil, nil
+	return replies, sent, dms
+}
+
+// Points the handlers to an empty in-memory store and a fresh scheduler for the duration of the test.
+func useMemoryStore(t *testing.T) {
+	t.Helper()
+
+	previousStore, previousScheduler, previousCatchUp := store, reminderScheduler, catchUp
+	store = newMemoryStore()
+	reminderScheduler = newScheduler()
+	catchUp = catchUpOnce
+
+	// The cached settings came from the previous store.
+	guildSettingsCacheMu.Lock()
+	clear(guildSettingsCache)
+	guildSettingsCacheMu.Unlock()
+	t.Cleanup(func() {
+		store, reminderScheduler, catchUp = previousStore, previousScheduler, previousCatchUp
+	})
+}
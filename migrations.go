@@ -0,0 +1,397 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Each version of the schema comes with a pair of files: `NNNN_name.up.sql` applying it and `NNNN_name.down.sql`
+// reverting it. They're templates, so that they can use the column types of the dialect.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRegex = regexp.MustCompile(`^(\d{4})_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+	// Of the up file, to notice the migrations changed after they had been applied.
+	checksum string
+}
+
+// What the migration templates can use.
+type migrationTemplateData struct {
+	IdColumn   string
+	TimeColumn string
+	Postgres   bool
+}
+
+// Returns the embedded migrations ordered by version. The versions have to start at 1 and can't have gaps.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		matches := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		contents, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: matches[2]}
+			byVersion[version] = m
+		} else if m.name != matches[2] {
+			return nil, fmt.Errorf("migrations %s and %s share the version %d", m.name, matches[2], version)
+		}
+
+		if matches[3] == "up" {
+			m.up = string(contents)
+			checksum := sha256.Sum256(contents)
+			m.checksum = hex.EncodeToString(checksum[:])
+		} else {
+			m.down = string(contents)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for version := 1; version <= len(byVersion); version++ {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is missing", version)
+		}
+		if len(m.up) == 0 || len(m.down) == 0 {
+			return nil, fmt.Errorf("migration %d (%s) needs both the up and the down file", version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return migrations, nil
+}
+
+func renderMigration(source string, dialect sqlDialect) (string, error) {
+	tmpl, err := template.New("migration").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, migrationTemplateData{
+		IdColumn:   dialect.idColumn(),
+		TimeColumn: dialect.timeColumn(),
+		Postgres:   dialect == dialectPostgres,
+	})
+
+	return rendered.String(), err
+}
+
+// A migration recorded in the Migrations table.
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+type migrator struct {
+	db         *sql.DB
+	dialect    sqlDialect
+	migrations []migration
+}
+
+// Creates the Migrations table if needed. The databases versioned before it existed get the migrations
+// up to their version recorded as applied.
+func newMigrator(db *sql.DB, dialect sqlDialect) (*migrator, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &migrator{db: db, dialect: dialect, migrations: migrations}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS Migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		appliedAt ` + dialect.timeColumn() + ` NOT NULL
+	);`)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.adoptLegacyVersion(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *migrator) tableExists(table string) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?"
+	if m.dialect == dialectPostgres {
+		// The unquoted names are folded to lowercase.
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema=current_schema() AND table_name=lower(?)"
+	}
+
+	var count int
+	err := m.db.QueryRow(m.dialect.rebind(query), table).Scan(&count)
+	return count > 0, err
+}
+
+// The schema used to be versioned with `PRAGMA user_version` and then with the SchemaVersion table,
+// both counting the same steps as the migrations.
+func (m *migrator) adoptLegacyVersion() error {
+	var recorded int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM Migrations").Scan(&recorded); err != nil {
+		return err
+	}
+	if recorded > 0 {
+		return nil
+	}
+
+	hasSchemaVersion, err := m.tableExists("SchemaVersion")
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if hasSchemaVersion {
+		err = m.db.QueryRow("SELECT version FROM SchemaVersion").Scan(&version)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	} else if m.dialect == dialectSqlite {
+		if err = m.db.QueryRow("PRAGMA user_version;").Scan(&version); err != nil {
+			return err
+		}
+	}
+
+	if version > len(m.migrations) {
+		return fmt.Errorf("the database is at version %d, but there are only %d migrations", version, len(m.migrations))
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, migration := range m.migrations[:version] {
+		if err = m.record(tx, migration); err != nil {
+			return err
+		}
+	}
+
+	if hasSchemaVersion {
+		if _, err = tx.Exec("DROP TABLE SchemaVersion"); err != nil {
+			return err
+		}
+	}
+
+	// Otherwise the version would be adopted again after reverting all the migrations.
+	if m.dialect == dialectSqlite {
+		if _, err = tx.Exec("PRAGMA user_version = 0;"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *migrator) record(tx *sql.Tx, migration migration) error {
+	_, err := tx.Exec(
+		m.dialect.rebind("INSERT INTO Migrations(version, name, checksum, appliedAt) VALUES(?,?,?,?)"),
+		migration.version, migration.name, migration.checksum, time.Now().UTC(),
+	)
+	return err
+}
+
+func (m *migrator) applied() (map[int]appliedMigration, error) {
+	rows, err := m.db.Query("SELECT version, checksum, appliedAt FROM Migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var (
+			version int
+			a       appliedMigration
+		)
+		if err = rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// Runs the rendered migration and records the new state of the Migrations table in a single transaction,
+// so that a failing migration leaves the schema as it was.
+func (m *migrator) run(migration migration, source string, update func(tx *sql.Tx) error) error {
+	statements, err := renderMigration(source, m.dialect)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(statements); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", migration.version, migration.name, err)
+	}
+
+	if err = update(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Applies the pending migrations in order and returns them. Refuses to run if any of the applied ones changed since.
+func (m *migrator) up() ([]migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []migration{}
+	for _, migration := range m.migrations {
+		a, ok := applied[migration.version]
+		if !ok {
+			pending = append(pending, migration)
+		} else if a.checksum != migration.checksum {
+			return nil, fmt.Errorf("migration %d (%s) was changed after it had been applied", migration.version, migration.name)
+		}
+	}
+
+	for _, migration := range pending {
+		err = m.run(migration, migration.up, func(tx *sql.Tx) error {
+			return m.record(tx, migration)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pending, nil
+}
+
+// Reverts the latest applied migration and returns it, or false if there wasn't any.
+func (m *migrator) down() (migration, bool, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return migration{}, false, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		latest := m.migrations[i]
+		if _, ok := applied[latest.version]; !ok {
+			continue
+		}
+
+		err = m.run(latest, latest.down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.dialect.rebind("DELETE FROM Migrations WHERE version=?"), latest.version)
+			return err
+		})
+
+		return latest, err == nil, err
+	}
+
+	return migration{}, false, nil
+}
+
+// Handles `gopnik migrate status|up|down`, using the same database the bot would.
+func runMigrateCommand(args []string) error {
+	if len(args) != 1 || !slices.Contains([]string{"status", "up", "down"}, args[0]) {
+		return errors.New("usage: gopnik migrate status|up|down")
+	}
+
+	dialect, dataSource, ok := sqlDataSource(os.Getenv("GOPNIK_DATABASE_URL"))
+	if !ok {
+		return errors.New("the in-memory store doesn't have anything to migrate")
+	}
+
+	db, err := sql.Open(string(dialect), dataSource)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := newMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := "pending"
+			if a, ok := applied[migration.version]; ok {
+				status = "applied " + a.appliedAt.Local().Format(time.DateTime)
+				if a.checksum != migration.checksum {
+					status += ", changed since"
+				}
+			}
+			fmt.Printf("%04d %-24s %s\n", migration.version, migration.name, status)
+		}
+	case "up":
+		migrated, err := m.up()
+		if err != nil {
+			return err
+		}
+
+		if len(migrated) == 0 {
+			fmt.Println("The database is already up to date.")
+		}
+		for _, migration := range migrated {
+			fmt.Printf("Applied %04d %s\n", migration.version, migration.name)
+		}
+	case "down":
+		reverted, ok, err := m.down()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			fmt.Println("There's nothing to revert.")
+		} else {
+			fmt.Printf("Reverted %04d %s\n", reverted.version, reverted.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS Reminders (
+	id {{.IdColumn}},
+	who TEXT NOT NULL,
+	time {{.TimeColumn}} NOT NULL,
+	toRemind TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS TimezonePreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL,
+	timezonePreference TEXT NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE Reminders DROP COLUMN recurring;
@@ -0,0 +1,2 @@
+-- Support recurring reminders.
+ALTER TABLE Reminders ADD recurring INTEGER NOT NULL DEFAULT 0;
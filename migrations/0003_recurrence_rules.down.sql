@@ -0,0 +1,4 @@
+-- Only the daily reminders stay recurring, the other rules can't be expressed with the flag.
+ALTER TABLE Reminders ADD recurring INTEGER NOT NULL DEFAULT 0;
+UPDATE Reminders SET recurring=1 WHERE recurrence='1 day';
+ALTER TABLE Reminders DROP COLUMN recurrence;
@@ -0,0 +1,4 @@
+-- Store recurrence rules instead of the daily-only flag.
+ALTER TABLE Reminders ADD recurrence TEXT NOT NULL DEFAULT '';
+UPDATE Reminders SET recurrence='1 day' WHERE recurring=1;
+ALTER TABLE Reminders DROP COLUMN recurring;
@@ -0,0 +1,2 @@
+ALTER TABLE Reminders DROP COLUMN wallClock;
+ALTER TABLE Reminders DROP COLUMN location;
@@ -0,0 +1,7 @@
+-- Anchor recurring reminders to the user's timezone. The existing ones keep following UTC,
+-- since there's no way to tell which timezone they were created in.
+ALTER TABLE Reminders ADD location TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE Reminders ADD wallClock TEXT NOT NULL DEFAULT '';
+UPDATE Reminders
+SET wallClock={{if .Postgres}}to_char(time AT TIME ZONE 'UTC', 'HH24:MI'){{else}}strftime('%H:%M', time){{end}}
+WHERE recurrence != '' AND recurrence NOT LIKE 'cron %' AND recurrence NOT LIKE '% hour%';
@@ -0,0 +1,3 @@
+ALTER TABLE Reminders DROP COLUMN messageId;
+ALTER TABLE Reminders DROP COLUMN channelId;
+ALTER TABLE Reminders DROP COLUMN guildId;
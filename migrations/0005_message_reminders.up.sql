@@ -0,0 +1,4 @@
+-- Support reminders about specific messages.
+ALTER TABLE Reminders ADD guildId TEXT NOT NULL DEFAULT '';
+ALTER TABLE Reminders ADD channelId TEXT NOT NULL DEFAULT '';
+ALTER TABLE Reminders ADD messageId TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DELETE FROM Reminders WHERE delivered=1;
+ALTER TABLE Reminders DROP COLUMN delivered;
@@ -0,0 +1,2 @@
+-- Keep the delivered one-time reminders around for snoozing.
+ALTER TABLE Reminders ADD delivered INTEGER NOT NULL DEFAULT 0;
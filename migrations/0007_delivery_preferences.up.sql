@@ -0,0 +1,6 @@
+-- Let users pick where their reminders are delivered.
+CREATE TABLE IF NOT EXISTS DeliveryPreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL UNIQUE,
+	deliveryPreference TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS RemindersByGuild;
+DROP TABLE IF EXISTS GuildSettings;
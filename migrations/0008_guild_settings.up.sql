@@ -0,0 +1,10 @@
+-- Support multiple guilds with their own configuration.
+CREATE TABLE IF NOT EXISTS GuildSettings (
+	guildId TEXT NOT NULL PRIMARY KEY,
+	remindersChannel TEXT NOT NULL DEFAULT '',
+	defaultTimezone TEXT NOT NULL DEFAULT '',
+	prefix TEXT NOT NULL DEFAULT '',
+	allowedRoles TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS RemindersByGuild ON Reminders(guildId, who);
@@ -0,0 +1,2 @@
+DELETE FROM Reminders WHERE platform != 'discord';
+ALTER TABLE Reminders DROP COLUMN platform;
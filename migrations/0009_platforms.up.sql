@@ -0,0 +1,2 @@
+-- Support other chat platforms than Discord.
+ALTER TABLE Reminders ADD platform TEXT NOT NULL DEFAULT 'discord';
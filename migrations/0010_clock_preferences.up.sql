@@ -0,0 +1,6 @@
+-- Let users pick between the 12-hour and the 24-hour clock.
+CREATE TABLE IF NOT EXISTS ClockPreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL UNIQUE,
+	clockPreference TEXT NOT NULL
+);
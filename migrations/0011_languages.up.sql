@@ -0,0 +1,6 @@
+-- Let users pick the language of the replies.
+CREATE TABLE IF NOT EXISTS LanguagePreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL UNIQUE,
+	language TEXT NOT NULL
+);
@@ -0,0 +1,6 @@
+-- Let users keep the reminders in their own words.
+CREATE TABLE IF NOT EXISTS PronounPreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL UNIQUE,
+	pronounPreference TEXT NOT NULL
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS RemindPreferences;
+ALTER TABLE GuildSettings DROP COLUMN pingRoles;
+DROP INDEX IF EXISTS RemindersByCreator;
+DELETE FROM Reminders WHERE creator != who OR targetRole != '';
+ALTER TABLE Reminders DROP COLUMN targetRole;
+ALTER TABLE Reminders DROP COLUMN creator;
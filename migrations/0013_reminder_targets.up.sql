@@ -0,0 +1,13 @@
+-- Support reminders set for other users, roles and everyone in the channel.
+ALTER TABLE Reminders ADD creator TEXT NOT NULL DEFAULT '';
+ALTER TABLE Reminders ADD targetRole TEXT NOT NULL DEFAULT '';
+UPDATE Reminders SET creator=who;
+CREATE INDEX IF NOT EXISTS RemindersByCreator ON Reminders(creator);
+
+ALTER TABLE GuildSettings ADD pingRoles TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS RemindPreferences (
+	id {{.IdColumn}},
+	who TEXT NOT NULL UNIQUE,
+	remindPreference TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Find the due and the old delivered reminders without scanning the whole table.
+CREATE INDEX IF NOT EXISTS RemindersByDueTime ON Reminders(delivered, time);
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Opens a new SQLite database, filled with the fixture from testdata unless it's empty.
+func openTestDb(t *testing.T, fixture string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open(string(dialectSqlite), filepath.Join(t.TempDir(), "reminders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if len(fixture) > 0 {
+		contents, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(string(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// Lists the columns of every table and the indexes, which don't depend on how the tables got them.
+func describeSchema(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT type, name, tbl_name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' ORDER BY type DESC, name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type object struct{ kind, name, table string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err = rows.Scan(&o.kind, &o.name, &o.table); err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+
+	var schema strings.Builder
+	for _, o := range objects {
+		var columns []string
+		if o.kind == "table" {
+			columns = queryStrings(t, db, "SELECT name || ' ' || type || IIF(\"notnull\", ' NOT NULL', '') || IIF(pk, ' PRIMARY KEY', '') || IFNULL(' DEFAULT ' || dflt_value, '') FROM pragma_table_info(?) ORDER BY cid", o.name)
+		} else {
+			columns = queryStrings(t, db, "SELECT name FROM pragma_index_info(?) ORDER BY seqno", o.name)
+			o.name += " ON " + o.table
+		}
+		fmt.Fprintf(&schema, "%s %s (%s)\n", o.kind, o.name, strings.Join(columns, ", "))
+	}
+
+	return schema.String()
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string, args ...any) []string {
+	t.Helper()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+
+	return values
+}
+
+func userVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version;").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+
+	return version
+}
+
+// Checks that exactly the first `count` migrations are recorded, with their current checksums.
+func expectApplied(t *testing.T, m *migrator, count int) {
+	t.Helper()
+
+	applied, err := m.applied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != count {
+		t.Fatalf("expected %d applied migrations, got %d", count, len(applied))
+	}
+
+	for _, migration := range m.migrations[:count] {
+		a, ok := applied[migration.version]
+		if !ok {
+			t.Fatalf("migration %d (%s) isn't recorded", migration.version, migration.name)
+		}
+		if a.checksum != migration.checksum {
+			t.Errorf("migration %d (%s) has the checksum %s, expected %s", migration.version, migration.name, a.checksum, migration.checksum)
+		}
+	}
+}
+
+func expectSchema(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "schema.sqlite.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema := describeSchema(t, db); schema != string(golden) {
+		t.Errorf("unexpected schema:\n%s\nexpected:\n%s", schema, golden)
+	}
+}
+
+// Reverts all the migrations, then applies them again.
+func expectRoundTrip(t *testing.T, m *migrator) {
+	t.Helper()
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		reverted, ok, err := m.down()
+		if err != nil || !ok {
+			t.Fatalf("reverting migration %d: %v", i+1, err)
+		}
+		if reverted.version != i+1 {
+			t.Fatalf("expected migration %d to be reverted, got %d", i+1, reverted.version)
+		}
+	}
+
+	if _, ok, err := m.down(); ok || err != nil {
+		t.Fatalf("expected nothing left to revert, got %v and %v", ok, err)
+	}
+	if schema := describeSchema(t, m.db); schema != "table Migrations (version INTEGER NOT NULL PRIMARY KEY, name TEXT NOT NULL, checksum TEXT NOT NULL, appliedAt DATETIME NOT NULL)\n" {
+		t.Fatalf("expected only the Migrations table to be left, got:\n%s", schema)
+	}
+
+	// The legacy version mustn't be adopted again.
+	m, err := newMigrator(m.db, dialectSqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectApplied(t, m, 0)
+
+	if migrated, err := m.up(); err != nil || len(migrated) != len(m.migrations) {
+		t.Fatalf("expected all the migrations to be applied again, got %d and %v", len(migrated), err)
+	}
+	expectApplied(t, m, len(m.migrations))
+	expectSchema(t, m.db)
+}
+
+func TestMigrateLegacyDatabases(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		adopted int
+	}{
+		{"new", "", 0},
+		{"v0", "legacy_v0.sql", 0},
+		{"v1", "legacy_v1.sql", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := openTestDb(t, test.fixture)
+
+			m, err := newMigrator(db, dialectSqlite)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectApplied(t, m, test.adopted)
+			if version := userVersion(t, db); version != 0 {
+				t.Errorf("expected the user version to be reset, got %d", version)
+			}
+
+			migrated, err := m.up()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrated) != len(m.migrations)-test.adopted || migrated[0].version != test.adopted+1 {
+				t.Errorf("expected the migrations from %d on to be applied, got %d of them", test.adopted+1, len(migrated))
+			}
+			expectApplied(t, m, len(m.migrations))
+			expectSchema(t, db)
+
+			if migrated, err = m.up(); err != nil || len(migrated) > 0 {
+				t.Errorf("expected the database to be up to date, got %d migrations and %v", len(migrated), err)
+			}
+
+			expectRoundTrip(t, m)
+		})
+	}
+}
+
+func TestMigrateKeepsTheLegacyData(t *testing.T) {
+	db := openTestDb(t, "legacy_v1.sql")
+
+	m, err := newMigrator(db, dialectSqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.up(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &sqlStore{db: db, dialect: dialectSqlite}
+	r, err := s.getReminder(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reminders from before the creators were stored are the reminded user's.
+	if r.who != "aurora" || r.creator != "aurora" || r.toRemind != "to buy milk" || r.platform != platformDiscord || r.delivered {
+		t.Errorf("unexpected reminder %+v", r)
+	}
+	if timezone, err := s.timezonePreference("aurora"); err != nil || timezone != "Europe/Warsaw" {
+		t.Errorf("expected the Europe/Warsaw timezone, got %q and %v", timezone, err)
+	}
+}
+
+// The versions between the user version and the Migrations table were kept in the SchemaVersion table, leaving
+// the user version behind.
+func TestMigrateSchemaVersionTable(t *testing.T) {
+	db := openTestDb(t, "legacy_v1.sql")
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range migrations[1:3] {
+		statements, err := renderMigration(migration.up, dialectSqlite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(statements); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = db.Exec("CREATE TABLE SchemaVersion (version INTEGER NOT NULL); INSERT INTO SchemaVersion(version) VALUES(3);"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := newMigrator(db, dialectSqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectApplied(t, m, 3)
+	if exists, err := m.tableExists("SchemaVersion"); err != nil || exists {
+		t.Errorf("expected the SchemaVersion table to be dropped, got %v and %v", exists, err)
+	}
+	if version := userVersion(t, db); version != 0 {
+		t.Errorf("expected the user version to be reset, got %d", version)
+	}
+
+	if _, err = m.up(); err != nil {
+		t.Fatal(err)
+	}
+	expectApplied(t, m, len(m.migrations))
+	expectSchema(t, db)
+}
+
+func TestMigrateRejectsUnknownVersions(t *testing.T) {
+	db := openTestDb(t, "legacy_v1.sql")
+	if _, err := db.Exec("PRAGMA user_version = 99;"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newMigrator(db, dialectSqlite); err == nil {
+		t.Error("expected a database from a newer version to be rejected")
+	}
+}
+
+func TestMigrateRejectsChangedMigrations(t *testing.T) {
+	db := openTestDb(t, "")
+
+	m, err := newMigrator(db, dialectSqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = db.Exec("UPDATE Migrations SET checksum='changed' WHERE version=2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.up(); err == nil || !strings.Contains(err.Error(), "migration 2 (recurring_reminders) was changed") {
+		t.Errorf("expected the changed migration to be reported, got %v", err)
+	}
+}
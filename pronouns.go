@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Decides whether the reminders are echoed back in the second person, e.g. "to call my mom" becoming
+// "to call your mom". Set with `!pronounpreference`.
+type pronounPreference string
+
+const (
+	pronounsRewrite pronounPreference = "on"
+	pronounsKeep    pronounPreference = "off"
+)
+
+// Returns the user's preference, falling back to rewriting the pronouns if it can't be read.
+func loadPronounPreference(who string) pronounPreference {
+	preference, err := store.pronounPreference(who)
+	if err != nil {
+		log.Println("Error querying the pronoun preference:", err)
+		return pronounsRewrite
+	}
+
+	return preference
+}
+
+// Swaps the first-person words of a language for the second-person ones.
+type pronounRewriter struct {
+	// The first-person words and their replacements, in lowercase and with straight apostrophes.
+	words map[string]string
+	// The subject pronoun, which is capitalized on its own, e.g. "I".
+	subject string
+	// The verbs that change right after the subject, e.g. "I am" becomes "you are".
+	verbsAfterSubject map[string]string
+	// The pronouns that change along with the preposition before them, e.g. "ze mną" becomes "z tobą".
+	phrases map[string]string
+}
+
+var pronounRewriters = map[language]pronounRewriter{
+	languageEnglish: {
+		words: map[string]string{
+			"i": "you", "me": "you", "my": "your", "mine": "yours", "myself": "yourself",
+			"i'm": "you're", "i've": "you've", "i'll": "you'll", "i'd": "you'd",
+		},
+		subject:           "i",
+		verbsAfterSubject: map[string]string{"am": "are", "was": "were", "wasn't": "weren't"},
+	},
+	languagePolish: {
+		words: map[string]string{
+			"mój": "twój", "moja": "twoja", "moje": "twoje", "mojego": "twojego", "mojej": "twojej", "mojemu": "twojemu",
+			"moim": "twoim", "moją": "twoją", "moich": "twoich", "moimi": "twoimi",
+			"mnie": "ciebie", "mi": "ci", "mną": "tobą",
+		},
+		phrases: map[string]string{
+			"ze mną": "z tobą", "nade mną": "nad tobą", "pode mną": "pod tobą", "przede mną": "przed tobą",
+			"ode mnie": "od ciebie", "beze mnie": "bez ciebie", "przeze mnie": "przez ciebie", "we mnie": "w tobie",
+			"o mnie": "o tobie", "przy mnie": "przy tobie", "ku mnie": "ku tobie",
+		},
+	},
+}
+
+var (
+	// The parts of the text that are left as they are: the code, the links, the mentions and the e-mail addresses.
+	verbatimTextRegex = regexp.MustCompile("(?s)```.*?```|`[^`]*`|https?://\\S+|www\\.\\S+|<[@#!][^>]*>|[\\w.+-]+@[\\w-]+\\.[\\w.]+|@[\\w.:-]+")
+	wordRegex         = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’][\p{L}]+)*`)
+	sentenceEndRegex  = regexp.MustCompile(`[.!?\n]`)
+)
+
+// Rewrites the reminder from the author's point of view to the bot's one, e.g. "I need to call my mom" becomes
+// "you need to call your mom", unless the author opted out. The text follows "reminding you", so it isn't
+// the start of a sentence.
+func (cmd *incomingCommand) rewritePronouns(text string) string {
+	if loadPronounPreference(cmd.author) == pronounsKeep {
+		return text
+	}
+
+	return pronounRewriters[cmd.language()].rewrite(text)
+}
+
+func (r pronounRewriter) rewrite(text string) string {
+	var (
+		rewritten     strings.Builder
+		last          int
+		sentenceStart bool
+		afterSubject  bool
+	)
+	for _, verbatim := range verbatimTextRegex.FindAllStringIndex(text, -1) {
+		rewritten.WriteString(r.rewriteWords(text[last:verbatim[0]], &sentenceStart, &afterSubject))
+		rewritten.WriteString(text[verbatim[0]:verbatim[1]])
+		sentenceStart, afterSubject = false, false
+		last = verbatim[1]
+	}
+	rewritten.WriteString(r.rewriteWords(text[last:], &sentenceStart, &afterSubject))
+
+	return rewritten.String()
+}
+
+// Rewrites the words of the text without any verbatim parts, keeping the state between the parts.
+func (r pronounRewriter) rewriteWords(text string, sentenceStart *bool, afterSubject *bool) string {
+	var (
+		rewritten bytes.Buffer
+		last      int
+		// The previous word, where it starts in the rewritten text and whether it started the sentence.
+		previous              string
+		previousStart         int
+		previousSentenceStart bool
+	)
+	for _, word := range wordRegex.FindAllStringIndex(text, -1) {
+		between := text[last:word[0]]
+		if sentenceEndRegex.MatchString(between) {
+			*sentenceStart = true
+		}
+		if len(strings.TrimSpace(between)) > 0 {
+			*afterSubject = false
+			previous = ""
+		}
+
+		original := text[word[0]:word[1]]
+		key := strings.ToLower(strings.ReplaceAll(original, "’", "'"))
+
+		if phrase, ok := r.phrases[strings.ToLower(previous)+" "+key]; ok {
+			preposition, pronoun, _ := strings.Cut(phrase, " ")
+			rewritten.Truncate(previousStart)
+			rewritten.WriteString(matchCase(previous, preposition, previousSentenceStart, false))
+			rewritten.WriteString(between)
+			rewritten.WriteString(matchCase(original, pronoun, false, false))
+
+			*afterSubject, *sentenceStart, previous = false, false, ""
+			last = word[1]
+			continue
+		}
+
+		rewritten.WriteString(between)
+		previous, previousStart, previousSentenceStart = original, rewritten.Len(), *sentenceStart
+
+		replacement, ok := r.words[key]
+		if !ok && *afterSubject {
+			replacement, ok = r.verbsAfterSubject[key]
+		}
+
+		if ok {
+			isSubject := len(r.subject) > 0 && (key == r.subject || strings.HasPrefix(key, r.subject+"'"))
+			rewritten.WriteString(matchApostrophe(matchCase(original, replacement, *sentenceStart, isSubject), original))
+		} else {
+			rewritten.WriteString(original)
+		}
+
+		*afterSubject = key == r.subject
+		*sentenceStart = false
+		last = word[1]
+	}
+	rewritten.WriteString(text[last:])
+
+	return rewritten.String()
+}
+
+// Writes the replacement like the original word: all caps, capitalized or lowercase. The subject is always
+// capitalized in English, so its replacement only gets capitalized at the start of a sentence.
+func matchCase(original string, replacement string, sentenceStart bool, isSubject bool) string {
+	letters := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return r
+		}
+		return -1
+	}, original)
+
+	switch {
+	case utf8.RuneCountInString(letters) > 1 && strings.ToUpper(letters) == letters:
+		return strings.ToUpper(replacement)
+	case sentenceStart || !isSubject && startsUpper(original):
+		first, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToUpper(first)) + replacement[size:]
+	default:
+		return replacement
+	}
+}
+
+func startsUpper(word string) bool {
+	first, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(first)
+}
+
+// Keeps the typographic apostrophe of the original, e.g. "I’m" becomes "you’re".
+func matchApostrophe(replacement string, original string) string {
+	if strings.Contains(original, "’") {
+		return strings.ReplaceAll(replacement, "'", "’")
+	}
+
+	return replacement
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// The cases in testdata/pronouns are separated by the lines with just `---`. The golden files have the rewritten
+// cases in the same order.
+const goldenCaseSeparator = "\n---\n"
+
+func TestRewritePronouns(t *testing.T) {
+	for _, lang := range []language{languageEnglish, languagePolish} {
+		t.Run(string(lang), func(t *testing.T) {
+			name := "english"
+			if lang == languagePolish {
+				name = "polish"
+			}
+
+			input, err := os.ReadFile(filepath.Join("testdata", "pronouns", name+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cases := strings.Split(strings.TrimSuffix(string(input), "\n"), goldenCaseSeparator)
+			rewritten := make([]string, len(cases))
+			for i, text := range cases {
+				rewritten[i] = pronounRewriters[lang].rewrite(text)
+			}
+
+			goldenPath := filepath.Join("testdata", "pronouns", name+".golden")
+			if *updateGolden {
+				if err = os.WriteFile(goldenPath, []byte(strings.Join(rewritten, goldenCaseSeparator)+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Split(strings.TrimSuffix(string(golden), "\n"), goldenCaseSeparator)
+			if len(expected) != len(cases) {
+				t.Fatalf("expected %d cases in %s, got %d, run the tests with -update", len(cases), goldenPath, len(expected))
+			}
+			for i, text := range cases {
+				if rewritten[i] != expected[i] {
+					t.Errorf("rewrote %q into %q, expected %q", text, rewritten[i], expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRewritePronounsFollowsThePreferences(t *testing.T) {
+	useMemoryStore(t)
+	if err := store.setLanguage("bruno", languagePolish); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.setPronounPreference("cyril", pronounsKeep); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		author   string
+		text     string
+		expected string
+	}{
+		{"aurora", "to call my mom", "to call your mom"},
+		{"bruno", "zadzwonić do mojej mamy", "zadzwonić do twojej mamy"},
+		{"cyril", "to call my mom", "to call my mom"},
+	}
+	for _, test := range tests {
+		cmd := &incomingCommand{messenger: newRecordingMessenger(), author: test.author}
+		if rewritten := cmd.rewritePronouns(test.text); rewritten != test.expected {
+			t.Errorf("rewrote %q for %s into %q, expected %q", test.text, test.author, rewritten, test.expected)
+		}
+	}
+}
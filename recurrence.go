@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var weekdaysByName = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+var workingDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Describes how often a recurring reminder fires. It's stored in the `recurrence` column of the Reminders
+// table as e.g. "1 day", "3 hour", "2 week friday", "1 day monday,tuesday,wednesday,thursday,friday"
+// or "cron CRON_TZ=Europe/Warsaw 0 9 1 * *". One-time reminders have an empty rule.
+type recurrence struct {
+	interval int
+	// Either "hour", "day" or "week".
+	unit string
+	// When non-empty, the occurrences only fall on these days.
+	weekdays []time.Weekday
+
+	// Set for the rules coming from cron expressions, in which case the fields above are unused.
+	// The spec is prefixed with CRON_TZ so that the schedule is evaluated in the user's timezone.
+	cronSpec     string
+	cronSchedule cron.Schedule
+
+	// The occurrences are computed in this location, so that e.g. a reminder set for 9 AM
+	// stays at 9 AM local time after a DST switch. Stored in the `location` column.
+	location *time.Location
+	// Local time of day ("15:04") the reminder fires at, stored in the `wallClock` column.
+	// Empty for the hourly and cron rules, which don't stick to a time of day.
+	wallClock string
+}
+
+// Parses the rule together with the location and the wall-clock time stored next to it.
+func loadRecurrence(rule string, locationName string, wallClock string) (recurrence, error) {
+	r, err := parseRecurrence(rule)
+	if err != nil {
+		return r, err
+	}
+
+	r.location, err = time.LoadLocation(locationName)
+	if err != nil {
+		return r, err
+	}
+
+	if len(wallClock) > 0 {
+		if _, err = time.Parse("15:04", wallClock); err != nil {
+			return r, fmt.Errorf("invalid wall-clock time %q: %w", wallClock, err)
+		}
+	}
+	r.wallClock = wallClock
+
+	return r, nil
+}
+
+func parseRecurrence(rule string) (recurrence, error) {
+	if spec, ok := strings.CutPrefix(rule, "cron "); ok {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return recurrence{}, fmt.Errorf("invalid cron expression in the recurrence rule %q: %w", rule, err)
+		}
+
+		return recurrence{cronSpec: spec, cronSchedule: schedule}, nil
+	}
+
+	fields := strings.Fields(rule)
+	if len(fields) < 2 || len(fields) > 3 {
+		return recurrence{}, fmt.Errorf("malformed recurrence rule %q", rule)
+	}
+
+	interval, err := strconv.Atoi(fields[0])
+	if err != nil || interval <= 0 {
+		return recurrence{}, fmt.Errorf("invalid interval in the recurrence rule %q", rule)
+	}
+
+	unit := fields[1]
+	if unit != "hour" && unit != "day" && unit != "week" {
+		return recurrence{}, fmt.Errorf("invalid unit in the recurrence rule %q", rule)
+	}
+
+	r := recurrence{interval: interval, unit: unit}
+	if len(fields) == 3 {
+		for _, name := range strings.Split(fields[2], ",") {
+			weekday, ok := weekdaysByName[name]
+			if !ok {
+				return recurrence{}, fmt.Errorf("invalid weekday in the recurrence rule %q", rule)
+			}
+			r.weekdays = append(r.weekdays, weekday)
+		}
+	}
+
+	return r, nil
+}
+
+// Builds the rule from the `!remindme every ...` syntax, e.g. "every 2 weeks on friday" is parsed from n = "2",
+// units = "weeks" and onWeekday = "friday". On invalid input, returns the message for the user.
+func recurrenceFromRemindme(lang language, n string, units string, onWeekday string) (recurrence, string, bool) {
+	r := recurrence{interval: 1}
+	if len(n) > 0 {
+		r.interval, _ = strconv.Atoi(n)
+		if r.interval == 0 {
+			return recurrence{}, "Every 0 what? You silly goose.", false
+		}
+	}
+
+	switch units {
+	case "hour", "hours":
+		r.unit = "hour"
+	case "day", "days":
+		r.unit = "day"
+	case "week", "weeks":
+		r.unit = "week"
+	case "weekday":
+		if len(n) > 0 {
+			return recurrence{}, "It's either every weekday or not at all.", false
+		}
+		r.unit = "day"
+		r.weekdays = workingDays
+	default:
+		if len(n) > 0 {
+			return recurrence{}, lang.sprintf("Use `every %s weeks on %s` instead.", n, units), false
+		}
+		r.unit = "week"
+		r.weekdays = []time.Weekday{weekdaysByName[units]}
+	}
+
+	if len(onWeekday) > 0 {
+		if r.unit != "week" || len(r.weekdays) > 0 {
+			return recurrence{}, "Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`.", false
+		}
+		r.weekdays = []time.Weekday{weekdaysByName[onWeekday]}
+	}
+
+	return r, "", true
+}
+
+// Builds the rule from the `!remindme cron "<expr>"` syntax. On invalid input, returns the message for the user.
+func recurrenceFromCron(lang language, expr string, location *time.Location) (recurrence, string, bool) {
+	if strings.Contains(expr, "TZ=") {
+		return recurrence{}, "Put the timezone after the expression instead of using `CRON_TZ`, e.g. `!remindme cron \"0 9 * * 1\" Europe/Berlin ...`.", false
+	}
+
+	spec := fmt.Sprintf("CRON_TZ=%s %s", location.String(), expr)
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return recurrence{}, lang.sprintf("That's not a valid cron expression: %s.", err), false
+	}
+
+	return recurrence{cronSpec: spec, cronSchedule: schedule, location: location}, "", true
+}
+
+func (r recurrence) String() string {
+	if r.cronSchedule != nil {
+		return "cron " + r.cronSpec
+	}
+
+	if len(r.weekdays) == 0 {
+		return fmt.Sprintf("%d %s", r.interval, r.unit)
+	}
+
+	names := make([]string, len(r.weekdays))
+	for i, weekday := range r.weekdays {
+		names[i] = strings.ToLower(weekday.String())
+	}
+
+	return fmt.Sprintf("%d %s %s", r.interval, r.unit, strings.Join(names, ","))
+}
+
+// Returns the cron expression the user typed in, without the CRON_TZ prefix.
+func (r recurrence) cronExpression() string {
+	_, expr, _ := strings.Cut(r.cronSpec, " ")
+	return expr
+}
+
+// Human-readable form of the rule used in the bot's replies, e.g. "every 2 weeks on friday".
+func (r recurrence) describe() string {
+	if r.cronSchedule != nil {
+		return fmt.Sprintf("on the `%s` cron schedule", r.cronExpression())
+	}
+
+	if r.unit == "day" && len(r.weekdays) == len(workingDays) {
+		return "every weekday"
+	}
+
+	var every string
+	if r.interval == 1 {
+		every = "every " + r.unit
+	} else {
+		every = fmt.Sprintf("every %d %ss", r.interval, r.unit)
+	}
+
+	if len(r.weekdays) == 0 {
+		return every
+	}
+
+	weekday := strings.ToLower(r.weekdays[0].String())
+	if r.interval == 1 {
+		return "every " + weekday
+	}
+
+	return every + " on " + weekday
+}
+
+func (r recurrence) allows(weekday time.Weekday) bool {
+	if len(r.weekdays) == 0 {
+		return true
+	}
+
+	for _, allowed := range r.weekdays {
+		if allowed == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Moves the time to the rule's wall-clock time on the same local day. The date arithmetic alone
+// isn't enough, since a time falling into a DST gap gets normalized and would drift from then on.
+func (r recurrence) atWallClock(t time.Time) time.Time {
+	clock, err := time.Parse("15:04", r.wallClock)
+	if err != nil {
+		return t
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), 0, 0, r.location)
+}
+
+// Returns the first occurrence after the previous one.
+func (r recurrence) next(previous time.Time) time.Time {
+	if r.cronSchedule != nil {
+		return r.nextCron(previous)
+	}
+
+	if r.location != nil {
+		previous = previous.In(r.location)
+	}
+
+	switch r.unit {
+	case "hour":
+		return previous.Add(time.Hour * time.Duration(r.interval))
+	case "week":
+		return r.atWallClock(previous.AddDate(0, 0, 7*r.interval))
+	default:
+		next := previous.AddDate(0, 0, r.interval)
+		for !r.allows(next.Weekday()) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return r.atWallClock(next)
+	}
+}
+
+// Evaluates the cron schedule on the wall clock of its timezone, so that, like with the other rules, an occurrence
+// falling into a DST gap moves past it instead of being skipped, and the one in the repeated hour only fires once.
+func (r recurrence) nextCron(previous time.Time) time.Time {
+	schedule, ok := r.cronSchedule.(*cron.SpecSchedule)
+	if !ok || schedule.Location == time.UTC {
+		return r.cronSchedule.Next(previous)
+	}
+
+	location := schedule.Location
+	wallClock := *schedule
+	wallClock.Location = time.UTC
+
+	local := previous.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	for {
+		next = wallClock.Next(next)
+		if next.IsZero() {
+			return next
+		}
+
+		// In the repeated hour, the wall-clock time can come out before the previous occurrence.
+		occurrence := time.Date(next.Year(), next.Month(), next.Day(), next.Hour(), next.Minute(), next.Second(), 0, location)
+		if occurrence.After(previous) {
+			return occurrence
+		}
+	}
+}
+
+// Returns the first occurrence after now, counting from the anchor, i.e. the time of day the user asked for.
+func (r recurrence) first(anchor time.Time, now time.Time) time.Time {
+	if r.unit == "hour" {
+		for !anchor.After(now) {
+			anchor = r.next(anchor)
+		}
+		return anchor
+	}
+
+	for !anchor.After(now) || !r.allows(anchor.Weekday()) {
+		anchor = r.atWallClock(anchor.AddDate(0, 0, 1))
+	}
+
+	return anchor
+}
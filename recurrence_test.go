@@ -0,0 +1,404 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// In 2026, Warsaw springs forward from 02:00 CET to 03:00 CEST on March 29 and falls back from 03:00 CEST to
+// 02:00 CET on October 25.
+const dstTimeLayout = "2006-01-02 15:04 MST"
+
+func TestNextAcrossDst(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		rule      string
+		wallClock string
+		start     string
+		expected  []string
+	}{
+		{"daily, spring forward", "1 day", "09:00", "2026-03-28 09:00 CET", []string{"2026-03-29 09:00 CEST", "2026-03-30 09:00 CEST"}},
+		{"daily in the gap", "1 day", "02:30", "2026-03-28 02:30 CET", []string{"2026-03-29 03:30 CEST", "2026-03-30 02:30 CEST"}},
+		{"daily, fall back", "1 day", "09:00", "2026-10-24 09:00 CEST", []string{"2026-10-25 09:00 CET", "2026-10-26 09:00 CET"}},
+		{"daily in the overlap", "1 day", "02:30", "2026-10-24 02:30 CEST", []string{"2026-10-25 02:30 CET", "2026-10-26 02:30 CET"}},
+		{"weekdays in the gap", "1 day monday,tuesday,wednesday,thursday,friday", "02:30", "2026-03-27 02:30 CET", []string{"2026-03-30 02:30 CEST"}},
+		{"weekly, spring forward", "1 week sunday", "09:00", "2026-03-22 09:00 CET", []string{"2026-03-29 09:00 CEST", "2026-04-05 09:00 CEST"}},
+		{"weekly in the gap", "1 week sunday", "02:30", "2026-03-22 02:30 CET", []string{"2026-03-29 03:30 CEST", "2026-04-05 02:30 CEST"}},
+		{"weekly, fall back", "1 week sunday", "09:00", "2026-10-18 09:00 CEST", []string{"2026-10-25 09:00 CET", "2026-11-01 09:00 CET"}},
+		{"weekly in the overlap", "1 week sunday", "02:30", "2026-10-18 02:30 CEST", []string{"2026-10-25 02:30 CET", "2026-11-01 02:30 CET"}},
+		{"biweekly across both", "2 week sunday", "02:30", "2026-03-15 02:30 CET", []string{"2026-03-29 03:30 CEST", "2026-04-12 02:30 CEST"}},
+		// The hourly reminders keep the real hour, not the one on the wall clock.
+		{"hourly, spring forward", "1 hour", "", "2026-03-29 01:30 CET", []string{"2026-03-29 03:30 CEST", "2026-03-29 04:30 CEST"}},
+		{"hourly, fall back", "1 hour", "", "2026-10-25 01:30 CEST", []string{"2026-10-25 02:30 CEST", "2026-10-25 02:30 CET", "2026-10-25 03:30 CET"}},
+		{"cron, spring forward", "cron CRON_TZ=Europe/Warsaw 0 9 * * *", "", "2026-03-28 09:00 CET", []string{"2026-03-29 09:00 CEST", "2026-03-30 09:00 CEST"}},
+		{"cron in the gap", "cron CRON_TZ=Europe/Warsaw 30 2 * * *", "", "2026-03-28 02:30 CET", []string{"2026-03-29 03:30 CEST", "2026-03-30 02:30 CEST"}},
+		{"cron, fall back", "cron CRON_TZ=Europe/Warsaw 0 9 * * 0", "", "2026-10-18 09:00 CEST", []string{"2026-10-25 09:00 CET", "2026-11-01 09:00 CET"}},
+		{"cron in the overlap", "cron CRON_TZ=Europe/Warsaw 30 2 * * *", "", "2026-10-24 02:30 CEST", []string{"2026-10-25 02:30 CET", "2026-10-26 02:30 CET"}},
+		// The repeated hour only happens once on the wall clock.
+		{"half-hourly cron in the overlap", "cron CRON_TZ=Europe/Warsaw */30 * * * *", "", "2026-10-25 01:30 CEST", []string{"2026-10-25 02:00 CET", "2026-10-25 02:30 CET", "2026-10-25 03:00 CET"}},
+		{"monthly cron in the gap", "cron CRON_TZ=Europe/Warsaw 30 2 29 * *", "", "2026-02-28 09:00 CET", []string{"2026-03-29 03:30 CEST", "2026-04-29 02:30 CEST"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := loadRecurrence(test.rule, "Europe/Warsaw", test.wallClock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			occurrence, err := time.ParseInLocation(dstTimeLayout, test.start, warsaw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The stored times are in UTC.
+			occurrence = occurrence.UTC()
+
+			for _, expected := range test.expected {
+				next := rule.next(occurrence)
+				if formatted := next.In(warsaw).Format(dstTimeLayout); formatted != expected {
+					t.Fatalf("after %s, expected %s, got %s", occurrence.In(warsaw).Format(dstTimeLayout), expected, formatted)
+				}
+				occurrence = next
+			}
+		})
+	}
+}
+
+func TestAtWallClockAcrossDst(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		wallClock string
+		day       time.Time
+		expected  string
+	}{
+		{"09:00", time.Date(2026, 3, 28, 23, 0, 0, 0, warsaw), "2026-03-28 09:00 CET"},
+		{"09:00", time.Date(2026, 3, 29, 0, 0, 0, 0, warsaw), "2026-03-29 09:00 CEST"},
+		// The gap is skipped over, the following days are back at 02:30.
+		{"02:30", time.Date(2026, 3, 29, 0, 0, 0, 0, warsaw), "2026-03-29 03:30 CEST"},
+		{"02:30", time.Date(2026, 3, 30, 12, 0, 0, 0, warsaw), "2026-03-30 02:30 CEST"},
+		{"02:30", time.Date(2026, 10, 25, 0, 0, 0, 0, warsaw), "2026-10-25 02:30 CET"},
+		{"09:00", time.Date(2026, 10, 25, 23, 59, 0, 0, warsaw), "2026-10-25 09:00 CET"},
+		// The day is the local one, not the one in UTC.
+		{"09:00", time.Date(2026, 10, 24, 23, 30, 0, 0, time.UTC).In(warsaw), "2026-10-25 09:00 CET"},
+	}
+
+	for _, test := range tests {
+		rule := recurrence{interval: 1, unit: "day", location: warsaw, wallClock: test.wallClock}
+		if formatted := rule.atWallClock(test.day).Format(dstTimeLayout); formatted != test.expected {
+			t.Errorf("atWallClock(%s) at %s = %s, expected %s", test.day.Format(dstTimeLayout), test.wallClock, formatted, test.expected)
+		}
+	}
+}
+
+func TestFirstAcrossDst(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule, err := loadRecurrence("1 day", "Europe/Warsaw", "02:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set on the evening before the switch, for 02:30 every day.
+	now := time.Date(2026, 3, 28, 22, 0, 0, 0, warsaw)
+	anchor := rule.atWallClock(now)
+	if first := rule.first(anchor, now).Format(dstTimeLayout); first != "2026-03-29 03:30 CEST" {
+		t.Errorf("expected the first occurrence right after the gap, got %s", first)
+	}
+}
+
+var recurringTimeRegex = regexp.MustCompile("^" + recurringTimeSyntax + "$")
+
+func TestRecurrenceFromRemindme(t *testing.T) {
+	tests := []struct {
+		input    string
+		rule     string
+		describe string
+	}{
+		{"every hour", "1 hour", "every hour"},
+		{"every 3 hours", "3 hour", "every 3 hours"},
+		{"every day at 8 PM", "1 day", "every day"},
+		{"every 2 days", "2 day", "every 2 days"},
+		{"every monday at 9 AM", "1 week monday", "every monday"},
+		{"every weekday at 10 AM", "1 day monday,tuesday,wednesday,thursday,friday", "every weekday"},
+		{"every week on sunday", "1 week sunday", "every sunday"},
+		{"every 2 weeks on friday", "2 week friday", "every 2 weeks on friday"},
+		{"every 2 weeks", "2 week", "every 2 weeks"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			matches := recurringTimeRegex.FindStringSubmatch(test.input)
+			if matches == nil {
+				t.Fatalf("%q doesn't match the recurring syntax", test.input)
+			}
+
+			rule, errMsg, ok := recurrenceFromRemindme(languageEnglish, matches[1], matches[2], matches[3])
+			if !ok {
+				t.Fatalf("expected the rule %q, got the error %q", test.rule, errMsg)
+			}
+			if rule.String() != test.rule {
+				t.Errorf("expected the rule %q, got %q", test.rule, rule.String())
+			}
+			if rule.describe() != test.describe {
+				t.Errorf("expected the description %q, got %q", test.describe, rule.describe())
+			}
+
+			// The rule has to come back the same from the database.
+			loaded, err := loadRecurrence(rule.String(), "Europe/Warsaw", "09:00")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.String() != test.rule || loaded.describe() != test.describe {
+				t.Errorf("expected the loaded rule %q (%q), got %q (%q)", test.rule, test.describe, loaded.String(), loaded.describe())
+			}
+		})
+	}
+}
+
+func TestRecurrenceFromRemindmeRejectsTheNonsense(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"every 0 days", "Every 0 what? You silly goose."},
+		{"every 2 weekday", "It's either every weekday or not at all."},
+		{"every 2 monday", "Use `every 2 weeks on monday` instead."},
+		{"every day on friday", "Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`."},
+		{"every 3 hours on friday", "Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`."},
+		{"every monday on friday", "Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`."},
+		{"every weekday on friday", "Picking the day with `on` only works with weeks, e.g. `every 2 weeks on friday`."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			matches := recurringTimeRegex.FindStringSubmatch(test.input)
+			if matches == nil {
+				t.Fatalf("%q doesn't match the recurring syntax", test.input)
+			}
+
+			rule, errMsg, ok := recurrenceFromRemindme(languageEnglish, matches[1], matches[2], matches[3])
+			if ok {
+				t.Fatalf("expected the error %q, got the rule %q", test.expected, rule.String())
+			}
+			if errMsg != test.expected {
+				t.Errorf("expected the error %q, got %q", test.expected, errMsg)
+			}
+		})
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule     string
+		describe string
+	}{
+		{"1 hour", "every hour"},
+		{"12 hour", "every 12 hours"},
+		{"1 day", "every day"},
+		{"1 day monday,tuesday,wednesday,thursday,friday", "every weekday"},
+		{"1 week saturday", "every saturday"},
+		{"3 week tuesday", "every 3 weeks on tuesday"},
+		{"cron CRON_TZ=Europe/Warsaw 0 9 1 * *", "on the `0 9 1 * *` cron schedule"},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRecurrence(test.rule)
+		if err != nil {
+			t.Errorf("parseRecurrence(%q) failed: %v", test.rule, err)
+			continue
+		}
+		if rule.String() != test.rule {
+			t.Errorf("parseRecurrence(%q).String() = %q", test.rule, rule.String())
+		}
+		if rule.describe() != test.describe {
+			t.Errorf("parseRecurrence(%q).describe() = %q, expected %q", test.rule, rule.describe(), test.describe)
+		}
+	}
+}
+
+func TestParseRecurrenceRejectsTheMalformedRules(t *testing.T) {
+	rules := []string{
+		"",
+		"day",
+		"1",
+		"0 day",
+		"-1 day",
+		"one day",
+		"1 month",
+		"1 days",
+		"1 week funday",
+		"1 week monday,",
+		"1 week monday tuesday",
+		"cron 61 * * * *",
+		"cron every day",
+	}
+
+	for _, rule := range rules {
+		if parsed, err := parseRecurrence(rule); err == nil {
+			t.Errorf("expected parseRecurrence(%q) to fail, got %q", rule, parsed.String())
+		}
+	}
+
+	if _, err := loadRecurrence("1 day", "Europe/Nowhere", ""); err == nil {
+		t.Error("expected an unknown location to fail")
+	}
+	if _, err := loadRecurrence("1 day", "Europe/Warsaw", "25:00"); err == nil {
+		t.Error("expected an invalid wall-clock time to fail")
+	}
+}
+
+func TestRemindmeCron(t *testing.T) {
+	useMemoryStore(t)
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday, in the default timezone.
+	useFixedClock(t, time.Date(2026, 10, 14, 12, 0, 0, 0, warsaw))
+
+	messenger := newRecordingMessenger()
+	cmd := &incomingCommand{messenger: messenger, author: "aurora", guildId: "guild", channelId: "channel"}
+	handleCommand(cmd, `!remindme cron "0 9 1 * *" to pay the rent`)
+
+	expected := "Successfully added to the database. I'll remind you to pay the rent on the `0 9 1 * *` cron schedule in the Europe/Warsaw timezone, next time on 01.11.2026 09:00 AM CET."
+	replies, _, _ := messenger.take()
+	if len(replies) != 1 || replies[0] != expected {
+		t.Fatalf("expected the reply %q, got %q", expected, replies)
+	}
+
+	reminders, err := store.listReminders("aurora", "guild")
+	if err != nil || len(reminders) != 1 {
+		t.Fatalf("expected a single reminder, got %+v and %v", reminders, err)
+	}
+	if reminders[0].recurrence != "cron CRON_TZ=Europe/Warsaw 0 9 1 * *" {
+		t.Errorf("expected the rule to keep the timezone, got %q", reminders[0].recurrence)
+	}
+	if at := reminders[0].time.In(warsaw).Format(dstTimeLayout); at != "2026-11-01 09:00 CET" {
+		t.Errorf("expected the reminder on 2026-11-01 09:00 CET, got %s", at)
+	}
+}
+
+func TestRecurrenceFromCron(t *testing.T) {
+	useMemoryStore(t)
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useFixedClock(t, time.Date(2026, 10, 14, 12, 0, 0, 0, warsaw))
+
+	tests := []struct {
+		input string
+		// The time in Warsaw, in dstTimeLayout. Empty when the expression is invalid.
+		at     string
+		rule   string
+		errMsg string
+	}{
+		{input: `cron "0 9 1 * *" to pay the rent`, at: "2026-11-01 09:00 CET", rule: "cron CRON_TZ=Europe/Warsaw 0 9 1 * *"},
+		{input: `cron "0 9 * * 1" America/New_York to call Ken`, at: "2026-10-19 15:00 CEST", rule: "cron CRON_TZ=America/New_York 0 9 * * 1"},
+		{input: `cron "0 9 * * 1" UTC to call Ken`, at: "2026-10-19 11:00 CEST", rule: "cron CRON_TZ=UTC 0 9 * * 1"},
+		{input: `cron "*/15 9-17 * * 1-5" to drink water`, at: "2026-10-14 12:15 CEST", rule: "cron CRON_TZ=Europe/Warsaw */15 9-17 * * 1-5"},
+		{input: `cron "@daily" to sleep`, at: "2026-10-15 00:00 CEST", rule: "cron CRON_TZ=Europe/Warsaw @daily"},
+
+		{input: `cron "0 9 * *" to pay the rent`, errMsg: "That's not a valid cron expression: expected exactly 5 fields, found 4: [0 9 * *]."},
+		{input: `cron "61 * * * *" to pay the rent`, errMsg: "That's not a valid cron expression: end of range (61) above maximum (59): 61."},
+		{input: `cron "every monday" to pay the rent`, errMsg: "That's not a valid cron expression: expected exactly 5 fields, found 2: [every monday]."},
+		{input: `cron "0 9 30 2 *" to pay the rent`, errMsg: "This cron expression never fires, who would've guessed?"},
+		{input: `cron "CRON_TZ=UTC 0 9 * * *" to pay the rent`, errMsg: "Put the timezone after the expression instead of using `CRON_TZ`, e.g. `!remindme cron \"0 9 * * 1\" Europe/Berlin ...`."},
+		{input: `cron "TZ=Asia/Tokyo 0 9 * * *" to pay the rent`, errMsg: "Put the timezone after the expression instead of using `CRON_TZ`, e.g. `!remindme cron \"0 9 * * 1\" Europe/Berlin ...`."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+			parsed, _, errMsg, ok, matched := splitTimeExpression(cmd, test.input)
+			if !matched {
+				t.Fatal("expected a time expression")
+			}
+
+			if len(test.errMsg) > 0 {
+				if ok || errMsg != test.errMsg {
+					t.Errorf("expected the error %q, got %q", test.errMsg, errMsg)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("unexpected error %q", errMsg)
+			}
+			if at := parsed.targetTime.In(warsaw).Format(dstTimeLayout); at != test.at {
+				t.Errorf("expected %s, got %s", test.at, at)
+			}
+			if parsed.rule != test.rule {
+				t.Errorf("expected the rule %q, got %q", test.rule, parsed.rule)
+			}
+
+			// The timezone stays in the stored rule, whatever the location column says.
+			loaded, err := loadRecurrence(parsed.rule, "UTC", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.String() != test.rule {
+				t.Errorf("expected the loaded rule %q, got %q", test.rule, loaded.String())
+			}
+			if next := loaded.next(timeNow()); !next.Equal(parsed.targetTime) {
+				t.Errorf("expected the loaded rule to fire at %s, got %s", test.at, next.In(warsaw).Format(dstTimeLayout))
+			}
+		})
+	}
+}
+
+func TestNextCron(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rule     string
+		previous string
+		expected []string
+	}{
+		{"cron CRON_TZ=Europe/Warsaw 0 9 1 * *", "2026-10-14 12:00 CEST", []string{"2026-11-01 09:00 CET", "2026-12-01 09:00 CET", "2027-01-01 09:00 CET"}},
+		// Right on the occurrence, the next one is a month later.
+		{"cron CRON_TZ=Europe/Warsaw 0 9 1 * *", "2026-03-01 09:00 CET", []string{"2026-04-01 09:00 CEST", "2026-05-01 09:00 CEST"}},
+		{"cron CRON_TZ=Europe/Warsaw 0 9 1 * *", "2026-03-01 08:59 CET", []string{"2026-03-01 09:00 CET"}},
+		// Without the wall clock to keep, the UTC schedules are evaluated as they are.
+		{"cron CRON_TZ=UTC 0 9 1 * *", "2026-10-14 12:00 CEST", []string{"2026-11-01 10:00 CET", "2026-12-01 10:00 CET"}},
+		{"cron CRON_TZ=America/New_York 0 9 1 * *", "2026-10-14 12:00 CEST", []string{"2026-11-01 15:00 CET", "2026-12-01 15:00 CET"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rule+" after "+test.previous, func(t *testing.T) {
+			rule, err := parseRecurrence(test.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			occurrence, err := time.ParseInLocation(dstTimeLayout, test.previous, warsaw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range test.expected {
+				next := rule.nextCron(occurrence.UTC())
+				if formatted := next.In(warsaw).Format(dstTimeLayout); formatted != expected {
+					t.Fatalf("after %s, expected %s, got %s", occurrence.In(warsaw).Format(dstTimeLayout), expected, formatted)
+				}
+				occurrence = next
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+type scheduledReminder struct {
+	id   int64
+	time time.Time
+	// Position in the heap, maintained by reminderQueue so that entries can be moved or removed.
+	index int
+}
+
+// Min-heap of the pending reminders ordered by their due time.
+type reminderQueue []*scheduledReminder
+
+func (q reminderQueue) Len() int           { return len(q) }
+func (q reminderQueue) Less(i, j int) bool { return q[i].time.Before(q[j].time) }
+
+func (q reminderQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *reminderQueue) Push(x any) {
+	reminder := x.(*scheduledReminder)
+	reminder.index = len(*q)
+	*q = append(*q, reminder)
+}
+
+func (q *reminderQueue) Pop() any {
+	old := *q
+	n := len(old)
+	reminder := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return reminder
+}
+
+// Keeps the IDs and due times of all the pending reminders in memory and sleeps until the earliest one
+// is due, so that the database only gets queried for the reminders that actually need to be sent.
+type scheduler struct {
+	mu    sync.Mutex
+	queue reminderQueue
+	byId  map[int64]*scheduledReminder
+	// Signaled whenever the earliest due time might have changed.
+	wake chan struct{}
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		byId: make(map[int64]*scheduledReminder),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Adds the reminder to the queue or moves it if it's already there.
+func (s *scheduler) schedule(id int64, dueTime time.Time) {
+	s.mu.Lock()
+	if reminder, ok := s.byId[id]; ok {
+		reminder.time = dueTime
+		heap.Fix(&s.queue, reminder.index)
+	} else {
+		reminder := &scheduledReminder{id: id, time: dueTime}
+		heap.Push(&s.queue, reminder)
+		s.byId[id] = reminder
+	}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *scheduler) unschedule(id int64) {
+	s.mu.Lock()
+	if reminder, ok := s.byId[id]; ok {
+		heap.Remove(&s.queue, reminder.index)
+		delete(s.byId, id)
+	}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending.
+	}
+}
+
+// Removes the reminders due at or before now from the queue and returns their IDs.
+func (s *scheduler) popDue(now time.Time) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []int64{}
+	for len(s.queue) > 0 && !s.queue[0].time.After(now) {
+		reminder := heap.Pop(&s.queue).(*scheduledReminder)
+		delete(s.byId, reminder.id)
+		due = append(due, reminder.id)
+	}
+
+	return due
+}
+
+func (s *scheduler) nextDueTime() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+
+	return s.queue[0].time, true
+}
+
+// Sleeps until the earliest reminder is due and calls fire with the IDs of the due ones, until stop gets closed.
+func (s *scheduler) run(stop <-chan struct{}, fire func(ids []int64)) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		timer.Stop()
+		if dueTime, ok := s.nextDueTime(); ok {
+			timer.Reset(time.Until(dueTime))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+			if due := s.popDue(time.Now()); len(due) > 0 {
+				fire(due)
+			}
+		}
+	}
+}
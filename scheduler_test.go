@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSchedulerFiresWithTheDueIds(t *testing.T) {
+	s := newScheduler()
+	now := time.Now()
+	s.schedule(1, now.Add(-time.Minute))
+	s.schedule(2, now.Add(-time.Second))
+	s.schedule(3, now.Add(time.Hour))
+	s.schedule(4, now.Add(-time.Hour))
+	s.unschedule(4)
+
+	fired := make(chan []int64, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.run(stop, func(ids []int64) {
+		fired <- ids
+	})
+
+	select {
+	case ids := <-fired:
+		if !slices.Equal(ids, []int64{1, 2}) {
+			t.Errorf("expected the reminders 1 and 2 to be due, got %v", ids)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scheduler didn't fire")
+	}
+
+	if dueTime, ok := s.nextDueTime(); !ok || !dueTime.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the reminder 3 to stay scheduled, got %v", dueTime)
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const slackApiUrl = "https://slack.com/api/"
+
+// Talks to Slack through the Web API, the commands come through the Events API and the slash commands
+// posted to the bot's HTTP server. For the commands, it also keeps where to reply.
+type slackMessenger struct {
+	token  string
+	apiUrl string
+	client *http.Client
+	// A bot token belongs to a single workspace, which plays the role of the guild.
+	teamId    string
+	botUserId string
+
+	// Set when the command comes from a slash command, the replies are sent there ephemerally.
+	responseUrl string
+	// Set when the command comes from a message, the replies are sent in its thread.
+	channelId string
+	threadTs  string
+}
+
+// Checks the token and finds out which workspace it belongs to. The API URL is only overridden
+// to talk to a stand-in of the Slack API.
+func newSlackMessenger(token string, apiUrl string) (*slackMessenger, error) {
+	m := &slackMessenger{token: token, apiUrl: apiUrl, client: &http.Client{Timeout: 10 * time.Second}}
+
+	var auth struct {
+		TeamId string `json:"team_id"`
+		UserId string `json:"user_id"`
+	}
+	if err := m.call("auth.test", url.Values{}, &auth); err != nil {
+		return nil, err
+	}
+	m.teamId = auth.TeamId
+	m.botUserId = auth.UserId
+
+	return m, nil
+}
+
+// Calls the Web API method and decodes the response into the result, unless it's nil.
+func (m *slackMessenger) call(method string, params url.Values, result any) error {
+	request, err := http.NewRequest(http.MethodPost, m.apiUrl+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+m.token)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	// Slack responds with 200 even for the failed calls, the outcome is in the body.
+	var status struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err = json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("%s: %s: %w", method, response.Status, err)
+	}
+	if !status.Ok {
+		return fmt.Errorf("%s: %s", method, status.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// Returns a copy of the messenger replying to the command.
+func (m *slackMessenger) forCommand(responseUrl string, channelId string, threadTs string) *slackMessenger {
+	replying := *m
+	replying.responseUrl = responseUrl
+	replying.channelId = channelId
+	replying.threadTs = threadTs
+	return &replying
+}
+
+func (m *slackMessenger) platform() string {
+	return platformSlack
+}
+
+func (m *slackMessenger) reply(cmd *incomingCommand, msg string) {
+	if len(m.responseUrl) == 0 {
+		// Slack notifies everyone mentioned in the text, e.g. the user who doesn't want to be reminded by others.
+		// The ephemeral responses below are only seen by the author, so they can keep the mentions.
+		params := url.Values{"channel": {m.channelId}, "text": {m.plainMentions(msg)}}
+		if len(m.threadTs) > 0 {
+			params.Set("thread_ts", m.threadTs)
+		}
+		if err := m.call("chat.postMessage", params, nil); err != nil {
+			log.Println("Error replying to the Slack message:", err)
+		}
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]string{"response_type": "ephemeral", "text": msg})
+	response, err := m.client.Post(m.responseUrl, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Println("Error responding to the Slack command:", err)
+		return
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		log.Println("Error responding to the Slack command:", response.Status)
+	}
+}
+
+func (m *slackMessenger) sendToChannel(channelId string, message outgoingMessage) error {
+	// There are no snooze buttons on Slack, they'd need the interactivity endpoint. Slack also can't be told whom
+	// to notify, everyone mentioned in the text is.
+	params := url.Values{"channel": {channelId}, "text": {message.content}}
+	if message.replyTo != nil {
+		params.Set("thread_ts", message.replyTo.messageId)
+	}
+
+	return m.call("chat.postMessage", params, nil)
+}
+
+func (m *slackMessenger) sendDm(who string, message outgoingMessage) error {
+	var conversation struct {
+		Channel struct {
+			Id string `json:"id"`
+		} `json:"channel"`
+	}
+	if err := m.call("conversations.open", url.Values{"users": {who}}, &conversation); err != nil {
+		return err
+	}
+
+	message.replyTo = nil
+	return m.sendToChannel(conversation.Channel.Id, message)
+}
+
+func (m *slackMessenger) mention(who string) string {
+	return fmt.Sprintf("<@%s>", who)
+}
+
+func (m *slackMessenger) mentionRole(role string) string {
+	if role == roleHere {
+		return "<!here>"
+	}
+
+	// The user groups are Slack's closest thing to the roles.
+	return fmt.Sprintf("<!subteam^%s>", role)
+}
+
+func (m *slackMessenger) escapeMentions(text string) string {
+	// Only the special mentions, e.g. a typed `@channel`, Slack can't be told not to notify the users anyway.
+	return slackLiveMentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		if strings.HasPrefix(mention, "<@") {
+			return mention
+		}
+
+		return m.plainMentions(mention)
+	})
+}
+
+var (
+	// The mentions come with the name, e.g. `<@U024BE7LH|aurora>`.
+	slackUserMentionRegex  = regexp.MustCompile(`^<@(\w+)(?:\|[^>]*)?>$`)
+	slackGroupMentionRegex = regexp.MustCompile(`^<!subteam\^(\w+)(?:\|[^>]*)?>$`)
+)
+
+func (m *slackMessenger) parseMention(mention string) (string, bool, bool) {
+	if matches := slackUserMentionRegex.FindStringSubmatch(mention); matches != nil {
+		return matches[1], false, true
+	}
+
+	if matches := slackGroupMentionRegex.FindStringSubmatch(mention); matches != nil {
+		return matches[1], true, true
+	}
+
+	return "", false, false
+}
+
+// The mentions which notify someone, optionally with the label to show, e.g. `<@U024BE7LH|aurora>` or `<!here>`.
+var slackLiveMentionRegex = regexp.MustCompile(`<(@\w+|!here|!channel|!everyone|!subteam\^\w+)(?:\|([^>]*))?>`)
+
+// Turns the mentions into plain text, e.g. `@aurora` or `@here`, which Slack doesn't notify anyone about.
+func (m *slackMessenger) plainMentions(text string) string {
+	return slackLiveMentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		matches := slackLiveMentionRegex.FindStringSubmatch(mention)
+		target, label := matches[1], matches[2]
+
+		switch {
+		case len(label) > 0:
+			return "@" + strings.TrimPrefix(label, "@")
+		case strings.HasPrefix(target, "@"):
+			return "@" + m.userName(target[1:])
+		default:
+			// Showing the user groups' handles would need another scope, they're left with their IDs.
+			return "@" + strings.TrimPrefix(target[1:], "subteam^")
+		}
+	})
+}
+
+// Returns the name the user is shown with, or their ID if it can't be found out.
+func (m *slackMessenger) userName(who string) string {
+	var info struct {
+		User struct {
+			Name    string `json:"name"`
+			Profile struct {
+				DisplayName string `json:"display_name"`
+				RealName    string `json:"real_name"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := m.call("users.info", url.Values{"user": {who}}, &info); err != nil {
+		log.Println("Error querying the user:", err)
+		return who
+	}
+
+	for _, name := range []string{info.User.Profile.DisplayName, info.User.Profile.RealName, info.User.Name} {
+		if len(name) > 0 {
+			return name
+		}
+	}
+
+	return who
+}
+
+func (m *slackMessenger) timestamp(t time.Time, location *time.Location, clock clockPreference) string {
+	// The fallback is only shown by the clients that can't format the date.
+	local := t.In(location)
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s %s %s>", t.Unix(), local.Format("02.01.2006"), clock.format(local), local.Format("MST"))
+}
+
+func (m *slackMessenger) messageLink(message messageRef) string {
+	// The message IDs are the timestamps, which the permalinks use without the dot.
+	return fmt.Sprintf("https://slack.com/archives/%s/p%s", message.channelId, strings.Replace(message.messageId, ".", "", 1))
+}
+
+func (m *slackMessenger) canManageGuild(cmd *incomingCommand) bool {
+	var info struct {
+		User struct {
+			IsAdmin bool `json:"is_admin"`
+			IsOwner bool `json:"is_owner"`
+		} `json:"user"`
+	}
+	if err := m.call("users.info", url.Values{"user": {cmd.author}}, &info); err != nil {
+		log.Println("Error checking the permissions:", err)
+		return false
+	}
+
+	return info.User.IsAdmin || info.User.IsOwner
+}
+
+func (m *slackMessenger) channelGuild(channelId string) (string, error) {
+	if err := m.call("conversations.info", url.Values{"channel": {channelId}}, nil); err != nil {
+		return "", err
+	}
+
+	return m.teamId, nil
+}
+
+// Makes sure the request was sent by Slack, as described in https://api.slack.com/authentication/verifying-requests-from-slack.
+// Returns the body of the request.
+func verifySlackRequest(request *http.Request, signingSecret string) ([]byte, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := request.Header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("missing timestamp")
+	}
+	// Protects against replaying old requests.
+	if time.Since(time.Unix(seconds, 0)).Abs() > 5*time.Minute {
+		return nil, errors.New("stale timestamp")
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(request.Header.Get("X-Slack-Signature"))) {
+		return nil, errors.New("invalid signature")
+	}
+
+	return body, nil
+}
+
+// The DM channels' IDs start with a D, they don't belong to the workspace the way the guild channels do.
+func (m *slackMessenger) guildOf(channelId string) string {
+	if strings.HasPrefix(channelId, "D") {
+		return ""
+	}
+
+	return m.teamId
+}
+
+// Handles the message events, translating the messages with the prefix into commands.
+func (m *slackMessenger) handleEvent(writer http.ResponseWriter, body []byte) {
+	var payload struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Event     struct {
+			Type    string `json:"type"`
+			Subtype string `json:"subtype"`
+			BotId   string `json:"bot_id"`
+			User    string `json:"user"`
+			Text    string `json:"text"`
+			Channel string `json:"channel"`
+			Ts      string `json:"ts"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(writer, "malformed event", http.StatusBadRequest)
+		return
+	}
+
+	// Slack checks the URL when it's set in the app's configuration.
+	if payload.Type == "url_verification" {
+		writer.Header().Set("Content-Type", "text/plain")
+		writer.Write([]byte(payload.Challenge))
+		return
+	}
+
+	// Slack expects the acknowledgement within 3 seconds, so the command is handled afterward.
+	writer.WriteHeader(http.StatusOK)
+
+	event := payload.Event
+	// Ignore the edits, the bot's own messages and other bots' shenanigans.
+	if payload.Type != "event_callback" || event.Type != "message" || len(event.Subtype) > 0 || len(event.BotId) > 0 || event.User == m.botUserId {
+		return
+	}
+
+	guildId := m.guildOf(event.Channel)
+	// The text is left escaped, so that the reminders are sent back to Slack the way they were typed.
+	content, ok := strings.CutPrefix(event.Text, loadGuildSettings(guildId).prefix)
+	if !ok {
+		return
+	}
+
+	cmd := incomingCommand{
+		messenger: m.forCommand("", event.Channel, event.Ts),
+		author:    event.User,
+		guildId:   guildId,
+		channelId: event.Channel,
+	}
+	go handleCommand(&cmd, defaultPrefix+content)
+}
+
+// Handles the slash commands, e.g. `/remindme in 2 days to buy a gift for Aurora`, which map directly
+// to the `!` prefix ones.
+func (m *slackMessenger) handleSlashCommand(writer http.ResponseWriter, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(writer, "malformed command", http.StatusBadRequest)
+		return
+	}
+
+	// An empty response acknowledges the command, the replies go to the response URL.
+	writer.WriteHeader(http.StatusOK)
+
+	channelId := form.Get("channel_id")
+	cmd := incomingCommand{
+		messenger: m.forCommand(form.Get("response_url"), channelId, ""),
+		author:    form.Get("user_id"),
+		guildId:   m.guildOf(channelId),
+		channelId: channelId,
+	}
+	content := joinCommand("!"+strings.TrimPrefix(form.Get("command"), "/"), strings.TrimSpace(form.Get("text")))
+	go handleCommand(&cmd, content)
+}
+
+// Serves the Events API at /slack/events and the slash commands at /slack/commands.
+func (m *slackMessenger) listen(address string, signingSecret string) error {
+	return http.ListenAndServe(address, m.handler(signingSecret))
+}
+
+// Routes the requests signed with the secret to the events and the slash commands.
+func (m *slackMessenger) handler(signingSecret string) http.Handler {
+	verified := func(handle func(writer http.ResponseWriter, body []byte)) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			body, err := verifySlackRequest(request, signingSecret)
+			if err != nil {
+				log.Println("Rejected a Slack request:", err)
+				http.Error(writer, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// The retries come when the acknowledgement was late, the command is already being handled.
+			if len(request.Header.Get("X-Slack-Retry-Num")) > 0 {
+				writer.WriteHeader(http.StatusOK)
+				return
+			}
+
+			handle(writer, body)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /slack/events", verified(m.handleEvent))
+	mux.HandleFunc("POST /slack/commands", verified(m.handleSlashCommand))
+
+	return mux
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// Stands in for the Slack Web API and the response URLs of the slash commands, passing every call on.
+type fakeSlack struct {
+	server *httptest.Server
+	// The parameters of the Web API calls, with the method in `method`, and the payloads posted to the response URLs.
+	calls     chan url.Values
+	responses chan map[string]string
+}
+
+func newFakeSlack(t *testing.T) *fakeSlack {
+	t.Helper()
+
+	fake := &fakeSlack{calls: make(chan url.Values, 16), responses: make(chan map[string]string, 16)}
+	fake.server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/respond" {
+			var payload map[string]string
+			if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+				t.Errorf("malformed response: %v", err)
+			}
+			fake.responses <- payload
+			return
+		}
+
+		if request.Header.Get("Authorization") != "Bearer xoxb-token" {
+			writer.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+
+		request.ParseForm()
+		method := strings.TrimPrefix(request.URL.Path, "/api/")
+		switch method {
+		case "auth.test":
+			writer.Write([]byte(`{"ok":true,"team_id":"T1","user_id":"UBOT"}`))
+			return
+		case "users.info":
+			writer.Write([]byte(`{"ok":true,"user":{"name":"aurora","profile":{"display_name":"Aurora"}}}`))
+			return
+		}
+
+		params := request.PostForm
+		params.Set("method", method)
+		fake.calls <- params
+		writer.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(fake.server.Close)
+
+	return fake
+}
+
+// Connects a messenger to the fake API and serves its events and slash commands.
+func (fake *fakeSlack) connect(t *testing.T) (*slackMessenger, *httptest.Server) {
+	t.Helper()
+
+	m, err := newSlackMessenger("xoxb-token", fake.server.URL+"/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.teamId != "T1" || m.botUserId != "UBOT" {
+		t.Fatalf("expected the workspace and the bot user from auth.test, got %q and %q", m.teamId, m.botUserId)
+	}
+
+	bot := httptest.NewServer(m.handler(testSigningSecret))
+	t.Cleanup(bot.Close)
+
+	return m, bot
+}
+
+// Signs the request the way Slack does, at the given time.
+func signedSlackRequest(t *testing.T, target string, body string, at time.Time) *http.Request {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	request.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return request
+}
+
+func post(t *testing.T, request *http.Request) (int, string) {
+	t.Helper()
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(body)
+}
+
+func TestVerifySlackRequest(t *testing.T) {
+	body := `{"type":"event_callback"}`
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		valid   bool
+	}{
+		{"valid", func() *http.Request {
+			return signedSlackRequest(t, "http://bot/slack/events", body, time.Now())
+		}, true},
+		{"stale", func() *http.Request {
+			return signedSlackRequest(t, "http://bot/slack/events", body, time.Now().Add(-6*time.Minute))
+		}, false},
+		{"from the future", func() *http.Request {
+			return signedSlackRequest(t, "http://bot/slack/events", body, time.Now().Add(6*time.Minute))
+		}, false},
+		{"bad signature", func() *http.Request {
+			request := signedSlackRequest(t, "http://bot/slack/events", body, time.Now())
+			request.Header.Set("X-Slack-Signature", "v0=0123456789abcdef")
+			return request
+		}, false},
+		{"tampered body", func() *http.Request {
+			request := signedSlackRequest(t, "http://bot/slack/events", body, time.Now())
+			request.Body = io.NopCloser(strings.NewReader(`{"type":"url_verification"}`))
+			return request
+		}, false},
+		{"missing timestamp", func() *http.Request {
+			request := signedSlackRequest(t, "http://bot/slack/events", body, time.Now())
+			request.Header.Del("X-Slack-Request-Timestamp")
+			return request
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verified, err := verifySlackRequest(test.request(), testSigningSecret)
+			if test.valid && (err != nil || string(verified) != body) {
+				t.Errorf("expected the body back, got %q and %v", verified, err)
+			}
+			if !test.valid && err == nil {
+				t.Error("expected the request to be rejected")
+			}
+		})
+	}
+}
+
+func TestSlackRejectsUnsignedRequests(t *testing.T) {
+	useMemoryStore(t)
+	_, bot := newFakeSlack(t).connect(t)
+
+	request := signedSlackRequest(t, bot.URL+"/slack/events", `{"type":"url_verification","challenge":"abc"}`, time.Now().Add(-time.Hour))
+	if status, _ := post(t, request); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a stale request, got %d", status)
+	}
+}
+
+func TestSlackUrlVerification(t *testing.T) {
+	useMemoryStore(t)
+	_, bot := newFakeSlack(t).connect(t)
+
+	body := `{"token":"Jhj5dZrVaK7ZwHHjRyZWjbDl","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`
+	status, response := post(t, signedSlackRequest(t, bot.URL+"/slack/events", body, time.Now()))
+	if status != http.StatusOK || response != "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P" {
+		t.Errorf("expected the challenge back, got %d %q", status, response)
+	}
+}
+
+func TestSlackSlashCommand(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeSlack(t)
+	_, bot := fake.connect(t)
+
+	form := url.Values{
+		"command":      {"/remindpreference"},
+		"text":         {" me "},
+		"user_id":      {"U2"},
+		"channel_id":   {"C1"},
+		"response_url": {fake.server.URL + "/respond"},
+	}
+	status, _ := post(t, signedSlackRequest(t, bot.URL+"/slack/commands", form.Encode(), time.Now()))
+	if status != http.StatusOK {
+		t.Fatalf("expected the command to be acknowledged, got %d", status)
+	}
+
+	select {
+	case response := <-fake.responses:
+		if response["response_type"] != "ephemeral" || !strings.HasPrefix(response["text"], "Successfully set the preference.") {
+			t.Errorf("unexpected response %v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command wasn't answered")
+	}
+
+	if preference, _ := store.remindPreference("U2"); preference != remindByMe {
+		t.Errorf("expected the `!remindpreference me` command, got the %q preference", preference)
+	}
+}
+
+func TestSlackMessageEvents(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeSlack(t)
+	_, bot := fake.connect(t)
+
+	event := func(fields string) string {
+		return `{"type":"event_callback","team_id":"T1","event":{"type":"message","channel":"C1","ts":"1700000000.000100",` + fields + `}}`
+	}
+	ignored := []string{
+		event(`"user":"U2","text":"!reminders","bot_id":"B1"`),
+		event(`"user":"UBOT","text":"!reminders"`),
+		event(`"subtype":"message_changed","user":"U2","text":"!reminders"`),
+		event(`"user":"U2","text":"reminders"`),
+		`{"type":"event_callback","event":{"type":"reaction_added","user":"U2","channel":"C1"}}`,
+	}
+	for _, body := range ignored {
+		if status, _ := post(t, signedSlackRequest(t, bot.URL+"/slack/events", body, time.Now())); status != http.StatusOK {
+			t.Errorf("expected the event to be acknowledged, got %d", status)
+		}
+	}
+
+	post(t, signedSlackRequest(t, bot.URL+"/slack/events", event(`"user":"U2","text":"!reminders"`), time.Now()))
+	select {
+	case call := <-fake.calls:
+		if call.Get("method") != "chat.postMessage" || call.Get("channel") != "C1" || call.Get("thread_ts") != "1700000000.000100" || call.Get("text") != "You have no pending reminders." {
+			t.Errorf("expected a reply in the thread, got %v", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command wasn't answered")
+	}
+
+	select {
+	case call := <-fake.calls:
+		t.Errorf("expected the other events to be ignored, got %v", call)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSlackMentions(t *testing.T) {
+	useMemoryStore(t)
+	fake := newFakeSlack(t)
+	m, _ := fake.connect(t)
+
+	// The reminder keeps the user mentions, but not the ones of the whole channel or the user groups.
+	escaped := m.escapeMentions("to ask <@U3> about <!channel> and <!subteam^S1|@devs> on <!date^1700000000^{date}|Nov 14>")
+	if escaped != "to ask <@U3> about @channel and @devs on <!date^1700000000^{date}|Nov 14>" {
+		t.Errorf("unexpected escaped text %q", escaped)
+	}
+
+	// The public replies don't notify anyone.
+	cmd := incomingCommand{messenger: m.forCommand("", "C1", "1700000000.000100"), author: "U2"}
+	cmd.reply("Sorry, <@U3> doesn't want to be reminded by others. <!here>")
+	select {
+	case call := <-fake.calls:
+		if call.Get("text") != "Sorry, @Aurora doesn't want to be reminded by others. @here" {
+			t.Errorf("expected plain mentions, got %q", call.Get("text"))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reply wasn't sent")
+	}
+}
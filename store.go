@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+var errReminderNotFound = errors.New("reminder not found")
+
+// A reminder as it's persisted.
+type reminder struct {
+	id       int64
+	who      string
+	time     time.Time
+	toRemind string
+	// Who set the reminder, differs from who for the reminders set for someone else with `!remind`.
+	creator string
+	// The role mentioned instead of the user, or roleHere for everyone in the channel, in which case the user
+	// is the creator. Empty for the reminders meant for the user alone.
+	targetRole string
+	// Recurrence rule, empty for one-time reminders.
+	recurrence string
+	location   string
+	wallClock  string
+	// Where the reminder was set. The message is only set for the reminders about a specific message.
+	guildId   string
+	channelId string
+	messageId string
+	// The chat platform the reminder was set on, e.g. "discord".
+	platform string
+	// The delivered one-time reminders are kept for a while, so that they can still be snoozed.
+	delivered bool
+}
+
+// Returns the timezone the reminder was set in, or UTC if it can't be loaded.
+func (r reminder) loadLocation() *time.Location {
+	location, err := time.LoadLocation(r.location)
+	if err != nil {
+		log.Println("Error loading the location:", err)
+		return time.UTC
+	}
+
+	return location
+}
+
+// Persists the reminders together with the users' preferences and the guilds' settings.
+// The handlers only talk to the storage through it, so that they can run against the in-memory implementation.
+type ReminderStore interface {
+	// Stores the new reminder and sets its ID.
+	createReminder(r *reminder) error
+	// Returns the pending reminders of the user ordered by time, both the ones for them and the ones they set
+	// for others, either from the given guild or from all of them when the guild is empty.
+	listReminders(who string, guildId string) ([]reminder, error)
+	// Returns errReminderNotFound if there isn't a reminder with that ID.
+	getReminder(id int64) (reminder, error)
+	deleteReminder(id int64) error
+	// Overwrites the text, the time, the recurrence and the delivery state of the reminder.
+	updateReminder(r reminder) error
+	// Moves the reminder to the new time and marks it as pending again.
+	updateReminderTime(id int64, newTime time.Time) error
+	markDelivered(id int64) error
+	// Returns the pending reminders due at or before the time, ordered by time.
+	dueBefore(t time.Time) ([]reminder, error)
+	// Returns the pending ones among the reminders with the given IDs, ordered by time.
+	pendingReminders(ids []int64) ([]reminder, error)
+	// Returns just the IDs and the due times of all the pending reminders, ordered by time, for the scheduler.
+	pendingDueTimes() ([]scheduledReminder, error)
+	// Deletes the delivered reminders that were due before the time.
+	purgeDelivered(before time.Time) error
+
+	// Returns an empty string if the user didn't set a preference.
+	timezonePreference(who string) (string, error)
+	setTimezonePreference(who string, timezone string) error
+	deliveryPreference(who string) (deliveryPreference, error)
+	setDeliveryPreference(who string, preference deliveryPreference) error
+	clockPreference(who string) (clockPreference, error)
+	setClockPreference(who string, preference clockPreference) error
+	language(who string) (language, error)
+	setLanguage(who string, lang language) error
+	pronounPreference(who string) (pronounPreference, error)
+	setPronounPreference(who string, preference pronounPreference) error
+	remindPreference(who string) (remindPreference, error)
+	setRemindPreference(who string, preference remindPreference) error
+
+	guildSettings(guildId string) (guildSettings, error)
+	// Stores a single setting, either "channel", "timezone", "prefix", "roles" or "pingroles".
+	setGuildSetting(guildId string, setting string, value string) error
+
+	close() error
+}
+
+// Returns the SQL dialect and the data source the database URL points to:
+//   - empty for the `reminders.db` SQLite file in the working directory,
+//   - `postgres://...` or `postgresql://...` for a PostgreSQL server,
+//   - anything else for the path of a SQLite file,
+//
+// or false for `memory`, which means the in-memory store.
+func sqlDataSource(databaseUrl string) (sqlDialect, string, bool) {
+	switch {
+	case len(databaseUrl) == 0:
+		return dialectSqlite, "reminders.db", true
+	case strings.HasPrefix(databaseUrl, "postgres://"), strings.HasPrefix(databaseUrl, "postgresql://"):
+		return dialectPostgres, databaseUrl, true
+	case databaseUrl == "memory":
+		return "", "", false
+	default:
+		return dialectSqlite, databaseUrl, true
+	}
+}
+
+func openStore(databaseUrl string) (ReminderStore, error) {
+	dialect, dataSource, ok := sqlDataSource(databaseUrl)
+	if !ok {
+		return newMemoryStore(), nil
+	}
+
+	return newSqlStore(dialect, dataSource)
+}
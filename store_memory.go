@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keeps everything in memory, nothing survives a restart. Meant for the tests and for trying the bot out.
+type memoryStore struct {
+	mu                  sync.Mutex
+	nextId              int64
+	reminders           map[int64]reminder
+	timezonePreferences map[string]string
+	deliveryPreferences map[string]deliveryPreference
+	clockPreferences    map[string]clockPreference
+	languages           map[string]language
+	pronounPreferences  map[string]pronounPreference
+	remindPreferences   map[string]remindPreference
+	guilds              map[string]guildSettings
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		nextId:              1,
+		reminders:           make(map[int64]reminder),
+		timezonePreferences: make(map[string]string),
+		deliveryPreferences: make(map[string]deliveryPreference),
+		clockPreferences:    make(map[string]clockPreference),
+		languages:           make(map[string]language),
+		pronounPreferences:  make(map[string]pronounPreference),
+		remindPreferences:   make(map[string]remindPreference),
+		guilds:              make(map[string]guildSettings),
+	}
+}
+
+// Returns the reminders passing the filter, ordered by time like the SQL implementations.
+func (s *memoryStore) filterReminders(keep func(r reminder) bool) []reminder {
+	reminders := []reminder{}
+	for _, r := range s.reminders {
+		if keep(r) {
+			reminders = append(reminders, r)
+		}
+	}
+
+	slices.SortFunc(reminders, func(a, b reminder) int {
+		if c := a.time.Compare(b.time); c != 0 {
+			return c
+		}
+		return int(a.id - b.id)
+	})
+
+	return reminders
+}
+
+func (s *memoryStore) createReminder(r *reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.id = s.nextId
+	s.nextId++
+	s.reminders[r.id] = *r
+
+	return nil
+}
+
+func (s *memoryStore) listReminders(who string, guildId string) ([]reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filterReminders(func(r reminder) bool {
+		return (r.who == who || r.creator == who) && !r.delivered && (len(guildId) == 0 || r.guildId == guildId)
+	}), nil
+}
+
+func (s *memoryStore) getReminder(id int64) (reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reminders[id]
+	if !ok {
+		return reminder{}, errReminderNotFound
+	}
+
+	return r, nil
+}
+
+func (s *memoryStore) deleteReminder(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reminders, id)
+	return nil
+}
+
+func (s *memoryStore) updateReminder(r reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.reminders[r.id]
+	if !ok {
+		return nil
+	}
+
+	existing.toRemind = r.toRemind
+	existing.time = r.time
+	existing.recurrence = r.recurrence
+	existing.location = r.location
+	existing.wallClock = r.wallClock
+	existing.delivered = r.delivered
+	s.reminders[r.id] = existing
+
+	return nil
+}
+
+func (s *memoryStore) updateReminderTime(id int64, newTime time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.reminders[id]; ok {
+		r.time = newTime
+		r.delivered = false
+		s.reminders[id] = r
+	}
+
+	return nil
+}
+
+func (s *memoryStore) markDelivered(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.reminders[id]; ok {
+		r.delivered = true
+		s.reminders[id] = r
+	}
+
+	return nil
+}
+
+func (s *memoryStore) dueBefore(t time.Time) ([]reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filterReminders(func(r reminder) bool {
+		return !r.delivered && !r.time.After(t)
+	}), nil
+}
+
+func (s *memoryStore) pendingReminders(ids []int64) ([]reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.filterReminders(func(r reminder) bool {
+		return !r.delivered && slices.Contains(ids, r.id)
+	}), nil
+}
+
+func (s *memoryStore) pendingDueTimes() ([]scheduledReminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := []scheduledReminder{}
+	for _, r := range s.filterReminders(func(r reminder) bool { return !r.delivered }) {
+		pending = append(pending, scheduledReminder{id: r.id, time: r.time})
+	}
+
+	return pending, nil
+}
+
+func (s *memoryStore) purgeDelivered(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.reminders {
+		if r.delivered && r.time.Before(before) {
+			delete(s.reminders, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) timezonePreference(who string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.timezonePreferences[who], nil
+}
+
+func (s *memoryStore) setTimezonePreference(who string, timezone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timezonePreferences[who] = timezone
+	return nil
+}
+
+func (s *memoryStore) deliveryPreference(who string) (deliveryPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference, ok := s.deliveryPreferences[who]; ok {
+		return preference, nil
+	}
+
+	return deliverDefault, nil
+}
+
+func (s *memoryStore) setDeliveryPreference(who string, preference deliveryPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference == deliverDefault {
+		delete(s.deliveryPreferences, who)
+	} else {
+		s.deliveryPreferences[who] = preference
+	}
+
+	return nil
+}
+
+func (s *memoryStore) clockPreference(who string) (clockPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference, ok := s.clockPreferences[who]; ok {
+		return preference, nil
+	}
+
+	return clock12h, nil
+}
+
+func (s *memoryStore) setClockPreference(who string, preference clockPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference == clock12h {
+		delete(s.clockPreferences, who)
+	} else {
+		s.clockPreferences[who] = preference
+	}
+
+	return nil
+}
+
+func (s *memoryStore) language(who string) (language, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lang, ok := s.languages[who]; ok {
+		return lang, nil
+	}
+
+	return languageEnglish, nil
+}
+
+func (s *memoryStore) setLanguage(who string, lang language) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lang == languageEnglish {
+		delete(s.languages, who)
+	} else {
+		s.languages[who] = lang
+	}
+
+	return nil
+}
+
+func (s *memoryStore) pronounPreference(who string) (pronounPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference, ok := s.pronounPreferences[who]; ok {
+		return preference, nil
+	}
+
+	return pronounsRewrite, nil
+}
+
+func (s *memoryStore) setPronounPreference(who string, preference pronounPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference == pronounsRewrite {
+		delete(s.pronounPreferences, who)
+	} else {
+		s.pronounPreferences[who] = preference
+	}
+
+	return nil
+}
+
+func (s *memoryStore) remindPreference(who string) (remindPreference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference, ok := s.remindPreferences[who]; ok {
+		return preference, nil
+	}
+
+	return remindByAnyone, nil
+}
+
+func (s *memoryStore) setRemindPreference(who string, preference remindPreference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preference == remindByAnyone {
+		delete(s.remindPreferences, who)
+	} else {
+		s.remindPreferences[who] = preference
+	}
+
+	return nil
+}
+
+func (s *memoryStore) guildSettings(guildId string) (guildSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.guilds[guildId], nil
+}
+
+func (s *memoryStore) setGuildSetting(guildId string, setting string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := s.guilds[guildId]
+	switch setting {
+	case "channel":
+		settings.channelId = value
+	case "timezone":
+		settings.defaultTimezone = value
+	case "prefix":
+		settings.prefix = value
+	case "roles":
+		settings.allowedRoles = nil
+		if len(value) > 0 {
+			settings.allowedRoles = strings.Split(value, ",")
+		}
+	case "pingroles":
+		settings.pingRoles = nil
+		if len(value) > 0 {
+			settings.pingRoles = strings.Split(value, ",")
+		}
+	default:
+		return fmt.Errorf("unknown guild setting %q", setting)
+	}
+	s.guilds[guildId] = settings
+
+	return nil
+}
+
+func (s *memoryStore) close() error {
+	return nil
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The SQL databases the store works with, named after their database/sql drivers.
+type sqlDialect string
+
+const (
+	dialectSqlite   sqlDialect = "sqlite3"
+	dialectPostgres sqlDialect = "postgres"
+)
+
+func (d sqlDialect) idColumn() string {
+	if d == dialectPostgres {
+		return "BIGSERIAL PRIMARY KEY"
+	}
+
+	return "INTEGER NOT NULL PRIMARY KEY"
+}
+
+func (d sqlDialect) timeColumn() string {
+	if d == dialectPostgres {
+		return "TIMESTAMPTZ"
+	}
+
+	return "DATETIME"
+}
+
+// Rewrites the `?` placeholders into the `$1` ones when needed.
+func (d sqlDialect) rebind(query string) string {
+	if d != dialectPostgres {
+		return query
+	}
+
+	var rebound strings.Builder
+	n := 0
+	for _, char := range query {
+		if char == '?' {
+			n++
+			rebound.WriteString(fmt.Sprintf("$%d", n))
+		} else {
+			rebound.WriteRune(char)
+		}
+	}
+
+	return rebound.String()
+}
+
+// Stores everything in a SQL database, either a SQLite file or a PostgreSQL server.
+type sqlStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+func newSqlStore(dialect sqlDialect, dataSource string) (ReminderStore, error) {
+	db, err := sql.Open(string(dialect), dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Brings the schema up to date on every start, `gopnik migrate` is only needed to check or revert it.
+	m, err := newMigrator(db, dialect)
+	if err == nil {
+		_, err = m.up()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqlStore{db: db, dialect: dialect}, nil
+}
+
+func (s *sqlStore) exec(query string, args ...any) (sql.Result, error) {
+	return s.db.Exec(s.dialect.rebind(query), args...)
+}
+
+func (s *sqlStore) query(query string, args ...any) (*sql.Rows, error) {
+	return s.db.Query(s.dialect.rebind(query), args...)
+}
+
+func (s *sqlStore) queryRow(query string, args ...any) *sql.Row {
+	return s.db.QueryRow(s.dialect.rebind(query), args...)
+}
+
+// The `delivered` column is an integer in both databases, but PostgreSQL doesn't convert the booleans.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+const reminderColumns = "id, who, creator, targetRole, time, toRemind, recurrence, location, wallClock, guildId, channelId, messageId, platform, delivered"
+
+func scanReminders(rows *sql.Rows) ([]reminder, error) {
+	defer rows.Close()
+
+	reminders := []reminder{}
+	for rows.Next() {
+		var r reminder
+		err := rows.Scan(&r.id, &r.who, &r.creator, &r.targetRole, &r.time, &r.toRemind, &r.recurrence, &r.location, &r.wallClock, &r.guildId, &r.channelId, &r.messageId, &r.platform, &r.delivered)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (s *sqlStore) createReminder(r *reminder) error {
+	// PostgreSQL doesn't support LastInsertId, but both support RETURNING.
+	return s.queryRow(`
+	INSERT INTO Reminders(who, creator, targetRole, time, toRemind, recurrence, location, wallClock, guildId, channelId, messageId, platform, delivered)
+	VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?)
+	RETURNING id
+	`, r.who, r.creator, r.targetRole, r.time, r.toRemind, r.recurrence, r.location, r.wallClock, r.guildId, r.channelId, r.messageId, r.platform, boolToInt(r.delivered)).Scan(&r.id)
+}
+
+func (s *sqlStore) listReminders(who string, guildId string) ([]reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM Reminders WHERE (who=? OR creator=?) AND delivered=0"
+	args := []any{who, who}
+	if len(guildId) > 0 {
+		query += " AND guildId=?"
+		args = append(args, guildId)
+	}
+
+	rows, err := s.query(query+" ORDER BY time, id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+func (s *sqlStore) getReminder(id int64) (reminder, error) {
+	rows, err := s.query("SELECT "+reminderColumns+" FROM Reminders WHERE id=?", id)
+	if err != nil {
+		return reminder{}, err
+	}
+
+	reminders, err := scanReminders(rows)
+	if err != nil {
+		return reminder{}, err
+	}
+
+	if len(reminders) == 0 {
+		return reminder{}, errReminderNotFound
+	}
+
+	return reminders[0], nil
+}
+
+func (s *sqlStore) deleteReminder(id int64) error {
+	_, err := s.exec("DELETE FROM Reminders WHERE id=?", id)
+	return err
+}
+
+func (s *sqlStore) updateReminder(r reminder) error {
+	_, err := s.exec(`
+	UPDATE Reminders
+	SET toRemind=?, time=?, recurrence=?, location=?, wallClock=?, delivered=?
+	WHERE id=?
+	`, r.toRemind, r.time, r.recurrence, r.location, r.wallClock, boolToInt(r.delivered), r.id)
+	return err
+}
+
+func (s *sqlStore) updateReminderTime(id int64, newTime time.Time) error {
+	_, err := s.exec("UPDATE Reminders SET time=?, delivered=0 WHERE id=?", newTime, id)
+	return err
+}
+
+func (s *sqlStore) markDelivered(id int64) error {
+	_, err := s.exec("UPDATE Reminders SET delivered=1 WHERE id=?", id)
+	return err
+}
+
+func (s *sqlStore) dueBefore(t time.Time) ([]reminder, error) {
+	rows, err := s.query("SELECT "+reminderColumns+" FROM Reminders WHERE delivered=0 AND time<=? ORDER BY time, id", t)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanReminders(rows)
+}
+
+func (s *sqlStore) pendingReminders(ids []int64) ([]reminder, error) {
+	// Keep the number of the query parameters well below SQLite's limit.
+	const batchSize = 500
+	reminders := []reminder{}
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+		placeholders := make([]string, len(batch))
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+
+		rows, err := s.query(fmt.Sprintf("SELECT "+reminderColumns+" FROM Reminders WHERE delivered=0 AND id IN (%s)", strings.Join(placeholders, ",")), args...)
+		if err != nil {
+			return nil, err
+		}
+
+		scanned, err := scanReminders(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, scanned...)
+	}
+
+	slices.SortFunc(reminders, func(a, b reminder) int {
+		if c := a.time.Compare(b.time); c != 0 {
+			return c
+		}
+		return int(a.id - b.id)
+	})
+
+	return reminders, nil
+}
+
+func (s *sqlStore) pendingDueTimes() ([]scheduledReminder, error) {
+	rows, err := s.query("SELECT id, time FROM Reminders WHERE delivered=0 ORDER BY time, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []scheduledReminder{}
+	for rows.Next() {
+		var r scheduledReminder
+		if err := rows.Scan(&r.id, &r.time); err != nil {
+			return nil, err
+		}
+		pending = append(pending, r)
+	}
+
+	return pending, rows.Err()
+}
+
+func (s *sqlStore) purgeDelivered(before time.Time) error {
+	_, err := s.exec("DELETE FROM Reminders WHERE delivered=1 AND time<?", before)
+	return err
+}
+
+func (s *sqlStore) timezonePreference(who string) (string, error) {
+	var timezone string
+	err := s.queryRow("SELECT timezonePreference FROM TimezonePreferences WHERE who=?", who).Scan(&timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return timezone, err
+}
+
+func (s *sqlStore) setTimezonePreference(who string, timezone string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The table doesn't have a unique constraint on who, so no upsert.
+	result, err := tx.Exec(s.dialect.rebind("UPDATE TimezonePreferences SET timezonePreference=? WHERE who=?"), timezone, who)
+	if err != nil {
+		return err
+	}
+
+	if updated, err := result.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		_, err = tx.Exec(s.dialect.rebind("INSERT INTO TimezonePreferences(who, timezonePreference) VALUES(?,?)"), who, timezone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) deliveryPreference(who string) (deliveryPreference, error) {
+	var preference deliveryPreference
+	err := s.queryRow("SELECT deliveryPreference FROM DeliveryPreferences WHERE who=?", who).Scan(&preference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return deliverDefault, nil
+	}
+
+	return preference, err
+}
+
+func (s *sqlStore) setDeliveryPreference(who string, preference deliveryPreference) error {
+	if preference == deliverDefault {
+		_, err := s.exec("DELETE FROM DeliveryPreferences WHERE who=?", who)
+		return err
+	}
+
+	_, err := s.exec(`
+	INSERT INTO DeliveryPreferences(who, deliveryPreference) VALUES(?,?)
+	ON CONFLICT(who) DO UPDATE SET deliveryPreference=excluded.deliveryPreference
+	`, who, preference)
+	return err
+}
+
+func (s *sqlStore) clockPreference(who string) (clockPreference, error) {
+	var preference clockPreference
+	err := s.queryRow("SELECT clockPreference FROM ClockPreferences WHERE who=?", who).Scan(&preference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return clock12h, nil
+	}
+
+	return preference, err
+}
+
+func (s *sqlStore) setClockPreference(who string, preference clockPreference) error {
+	if preference == clock12h {
+		_, err := s.exec("DELETE FROM ClockPreferences WHERE who=?", who)
+		return err
+	}
+
+	_, err := s.exec(`
+	INSERT INTO ClockPreferences(who, clockPreference) VALUES(?,?)
+	ON CONFLICT(who) DO UPDATE SET clockPreference=excluded.clockPreference
+	`, who, preference)
+	return err
+}
+
+func (s *sqlStore) language(who string) (language, error) {
+	var lang language
+	err := s.queryRow("SELECT language FROM LanguagePreferences WHERE who=?", who).Scan(&lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return languageEnglish, nil
+	}
+
+	return lang, err
+}
+
+func (s *sqlStore) setLanguage(who string, lang language) error {
+	if lang == languageEnglish {
+		_, err := s.exec("DELETE FROM LanguagePreferences WHERE who=?", who)
+		return err
+	}
+
+	_, err := s.exec(`
+	INSERT INTO LanguagePreferences(who, language) VALUES(?,?)
+	ON CONFLICT(who) DO UPDATE SET language=excluded.language
+	`, who, lang)
+	return err
+}
+
+func (s *sqlStore) pronounPreference(who string) (pronounPreference, error) {
+	var preference pronounPreference
+	err := s.queryRow("SELECT pronounPreference FROM PronounPreferences WHERE who=?", who).Scan(&preference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pronounsRewrite, nil
+	}
+
+	return preference, err
+}
+
+func (s *sqlStore) setPronounPreference(who string, preference pronounPreference) error {
+	if preference == pronounsRewrite {
+		_, err := s.exec("DELETE FROM PronounPreferences WHERE who=?", who)
+		return err
+	}
+
+	_, err := s.exec(`
+	INSERT INTO PronounPreferences(who, pronounPreference) VALUES(?,?)
+	ON CONFLICT(who) DO UPDATE SET pronounPreference=excluded.pronounPreference
+	`, who, preference)
+	return err
+}
+
+func (s *sqlStore) remindPreference(who string) (remindPreference, error) {
+	var preference remindPreference
+	err := s.queryRow("SELECT remindPreference FROM RemindPreferences WHERE who=?", who).Scan(&preference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return remindByAnyone, nil
+	}
+
+	return preference, err
+}
+
+func (s *sqlStore) setRemindPreference(who string, preference remindPreference) error {
+	if preference == remindByAnyone {
+		_, err := s.exec("DELETE FROM RemindPreferences WHERE who=?", who)
+		return err
+	}
+
+	_, err := s.exec(`
+	INSERT INTO RemindPreferences(who, remindPreference) VALUES(?,?)
+	ON CONFLICT(who) DO UPDATE SET remindPreference=excluded.remindPreference
+	`, who, preference)
+	return err
+}
+
+func (s *sqlStore) guildSettings(guildId string) (guildSettings, error) {
+	var (
+		settings     guildSettings
+		allowedRoles string
+		pingRoles    string
+	)
+	err := s.queryRow(
+		"SELECT remindersChannel, defaultTimezone, prefix, allowedRoles, pingRoles FROM GuildSettings WHERE guildId=?", guildId,
+	).Scan(&settings.channelId, &settings.defaultTimezone, &settings.prefix, &allowedRoles, &pingRoles)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return guildSettings{}, err
+	}
+
+	if len(allowedRoles) > 0 {
+		settings.allowedRoles = strings.Split(allowedRoles, ",")
+	}
+	if len(pingRoles) > 0 {
+		settings.pingRoles = strings.Split(pingRoles, ",")
+	}
+
+	return settings, nil
+}
+
+// Maps the settings to the GuildSettings columns.
+var guildSettingColumns = map[string]string{
+	"channel":   "remindersChannel",
+	"timezone":  "defaultTimezone",
+	"prefix":    "prefix",
+	"roles":     "allowedRoles",
+	"pingroles": "pingRoles",
+}
+
+func (s *sqlStore) setGuildSetting(guildId string, setting string, value string) error {
+	column, ok := guildSettingColumns[setting]
+	if !ok {
+		return fmt.Errorf("unknown guild setting %q", setting)
+	}
+
+	_, err := s.exec(
+		fmt.Sprintf("INSERT INTO GuildSettings(guildId, %[1]s) VALUES(?,?) ON CONFLICT(guildId) DO UPDATE SET %[1]s=excluded.%[1]s", column),
+		guildId, value,
+	)
+	return err
+}
+
+func (s *sqlStore) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Runs the test against every store: the in-memory one, SQLite, and PostgreSQL when GOPNIK_DATABASE_URL points
+// to a server. Each run gets an empty store.
+func forEachStore(t *testing.T, test func(t *testing.T, s ReminderStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryStore())
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := newSqlStore(dialectSqlite, filepath.Join(t.TempDir(), "reminders.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.close() })
+
+		test(t, s)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		dialect, dataSource, ok := sqlDataSource(os.Getenv("GOPNIK_DATABASE_URL"))
+		if !ok || dialect != dialectPostgres {
+			t.Skip("GOPNIK_DATABASE_URL doesn't point to a PostgreSQL server")
+		}
+
+		test(t, newPostgresTestStore(t, dataSource))
+	})
+}
+
+// Creates a schema of its own for the test, so that it starts empty and doesn't touch the existing tables.
+func newPostgresTestStore(t *testing.T, dataSource string) ReminderStore {
+	t.Helper()
+
+	db, err := sql.Open(string(dialectPostgres), dataSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := "gopnik_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err = db.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	separator := "?"
+	if strings.Contains(dataSource, "?") {
+		separator = "&"
+	}
+	s, err := newSqlStore(dialectPostgres, dataSource+separator+"search_path="+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.close() })
+
+	return s
+}
+
+// The times are stored with a precision of a microsecond at best.
+var storeTestTime = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+func createStoreTestReminder(t *testing.T, s ReminderStore, r reminder) reminder {
+	t.Helper()
+
+	if len(r.creator) == 0 {
+		r.creator = r.who
+	}
+	if len(r.location) == 0 {
+		r.location = "UTC"
+	}
+	if len(r.platform) == 0 {
+		r.platform = platformDiscord
+	}
+	if err := s.createReminder(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// Checks the reminders field by field, comparing the times as instants.
+func expectReminders(t *testing.T, got []reminder, err error, expected ...reminder) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d reminders, got %+v", len(expected), got)
+	}
+
+	for i := range expected {
+		if !got[i].time.Equal(expected[i].time) {
+			t.Errorf("reminder %d: expected the time %v, got %v", i, expected[i].time, got[i].time)
+		}
+		got[i].time, expected[i].time = time.Time{}, time.Time{}
+		if got[i] != expected[i] {
+			t.Errorf("reminder %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestStoreReminders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		created := createStoreTestReminder(t, s, reminder{
+			who: "bruno", creator: "aurora", time: storeTestTime, toRemind: "to call mom", recurrence: "1 day",
+			location: "Europe/Warsaw", wallClock: "12:00", guildId: "guild", channelId: "channel", messageId: "message",
+			platform: platformSlack,
+		})
+		other := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(time.Hour), toRemind: "to buy milk"})
+		if created.id == 0 || other.id == 0 || created.id == other.id {
+			t.Fatalf("expected distinct IDs, got %d and %d", created.id, other.id)
+		}
+
+		r, err := s.getReminder(created.id)
+		expectReminders(t, []reminder{r}, err, created)
+
+		if _, err = s.getReminder(other.id + 100); !errors.Is(err, errReminderNotFound) {
+			t.Errorf("expected errReminderNotFound, got %v", err)
+		}
+
+		// Only the text, the time, the recurrence and the delivery state change.
+		updated := created
+		updated.toRemind, updated.time, updated.recurrence, updated.location, updated.wallClock, updated.delivered = "to call dad", storeTestTime.Add(2*time.Hour), "", "UTC", "", true
+		changed := updated
+		changed.who, changed.creator, changed.guildId = "cyril", "cyril", "other"
+		if err = s.updateReminder(changed); err != nil {
+			t.Fatal(err)
+		}
+		r, err = s.getReminder(created.id)
+		expectReminders(t, []reminder{r}, err, updated)
+
+		if err = s.updateReminderTime(created.id, storeTestTime.Add(3*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		updated.time, updated.delivered = storeTestTime.Add(3*time.Hour), false
+		r, err = s.getReminder(created.id)
+		expectReminders(t, []reminder{r}, err, updated)
+
+		if err = s.markDelivered(created.id); err != nil {
+			t.Fatal(err)
+		}
+		updated.delivered = true
+		r, err = s.getReminder(created.id)
+		expectReminders(t, []reminder{r}, err, updated)
+
+		if err = s.deleteReminder(created.id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.getReminder(created.id); !errors.Is(err, errReminderNotFound) {
+			t.Errorf("expected the reminder to be deleted, got %v", err)
+		}
+		r, err = s.getReminder(other.id)
+		expectReminders(t, []reminder{r}, err, other)
+
+		// Changing the reminders that don't exist isn't an error.
+		for _, err = range []error{s.updateReminderTime(created.id, storeTestTime), s.markDelivered(created.id), s.deleteReminder(created.id)} {
+			if err != nil {
+				t.Errorf("expected the missing reminder to be ignored, got %v", err)
+			}
+		}
+	})
+}
+
+func TestStoreListReminders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		later := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(time.Hour), toRemind: "to buy milk", guildId: "guild"})
+		forBruno := createStoreTestReminder(t, s, reminder{who: "bruno", creator: "aurora", time: storeTestTime, toRemind: "to call mom", guildId: "guild"})
+		fromBruno := createStoreTestReminder(t, s, reminder{who: "aurora", creator: "bruno", time: storeTestTime.Add(2 * time.Hour), toRemind: "to call back", guildId: "other"})
+		forRole := createStoreTestReminder(t, s, reminder{who: "aurora", targetRole: "moderators", time: storeTestTime.Add(30 * time.Minute), toRemind: "to vote", guildId: "guild"})
+		createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime, toRemind: "to water the plants", guildId: "guild", delivered: true})
+		createStoreTestReminder(t, s, reminder{who: "cyril", time: storeTestTime, toRemind: "to feed the cat", guildId: "guild"})
+
+		reminders, err := s.listReminders("aurora", "")
+		expectReminders(t, reminders, err, forBruno, forRole, later, fromBruno)
+
+		reminders, err = s.listReminders("aurora", "guild")
+		expectReminders(t, reminders, err, forBruno, forRole, later)
+
+		reminders, err = s.listReminders("bruno", "")
+		expectReminders(t, reminders, err, forBruno, fromBruno)
+
+		reminders, err = s.listReminders("nobody", "")
+		expectReminders(t, reminders, err)
+	})
+}
+
+func TestStoreDueReminders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		first := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(-time.Hour), toRemind: "to buy milk"})
+		// Due at the same time, ordered by ID.
+		second := createStoreTestReminder(t, s, reminder{who: "bruno", time: storeTestTime, toRemind: "to call mom"})
+		third := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime, toRemind: "to call dad"})
+		future := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(time.Second), toRemind: "to stretch"})
+		delivered := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(-2 * time.Hour), toRemind: "to water the plants"})
+		if err := s.markDelivered(delivered.id); err != nil {
+			t.Fatal(err)
+		}
+		delivered.delivered = true
+
+		reminders, err := s.dueBefore(storeTestTime)
+		expectReminders(t, reminders, err, first, second, third)
+
+		reminders, err = s.pendingReminders([]int64{future.id, third.id, delivered.id, second.id, future.id + 100})
+		expectReminders(t, reminders, err, second, third, future)
+
+		reminders, err = s.pendingReminders(nil)
+		expectReminders(t, reminders, err)
+
+		// Only the delivered reminders due before the time are purged.
+		if err = s.markDelivered(future.id); err != nil {
+			t.Fatal(err)
+		}
+		future.delivered = true
+		if err = s.purgeDelivered(storeTestTime); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.getReminder(delivered.id); !errors.Is(err, errReminderNotFound) {
+			t.Errorf("expected the delivered reminder to be purged, got %v", err)
+		}
+		r, err := s.getReminder(future.id)
+		expectReminders(t, []reminder{r}, err, future)
+		reminders, err = s.dueBefore(storeTestTime.Add(time.Hour))
+		expectReminders(t, reminders, err, first, second, third)
+	})
+}
+
+func TestStorePendingDueTimes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		later := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(time.Hour), toRemind: "to stretch"})
+		// Due at the same time, ordered by ID.
+		first := createStoreTestReminder(t, s, reminder{who: "bruno", time: storeTestTime, toRemind: "to call mom"})
+		second := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime, toRemind: "to call dad", recurrence: "1 day"})
+		// Even the ones set for the end of time.
+		last := createStoreTestReminder(t, s, reminder{who: "aurora", time: time.Date(9999, 12, 31, 12, 0, 0, 0, time.UTC), toRemind: "to turn off the lights"})
+		delivered := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(-time.Hour), toRemind: "to water the plants"})
+		if err := s.markDelivered(delivered.id); err != nil {
+			t.Fatal(err)
+		}
+
+		pending, err := s.pendingDueTimes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []reminder{first, second, later, last}
+		if len(pending) != len(expected) {
+			t.Fatalf("expected %d pending reminders, got %+v", len(expected), pending)
+		}
+		for i, r := range pending {
+			if r.id != expected[i].id || !r.time.Equal(expected[i].time) {
+				t.Errorf("expected the reminder %d due at %v, got %d due at %v", expected[i].id, expected[i].time, r.id, r.time)
+			}
+		}
+	})
+}
+
+// There are more IDs than SQLite allows the query parameters.
+func TestStorePendingRemindersInBatches(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		ids := []int64{}
+		for i := 0; i < 1200; i++ {
+			r := createStoreTestReminder(t, s, reminder{who: "aurora", time: storeTestTime.Add(-time.Duration(i) * time.Second), toRemind: "to count " + strconv.Itoa(i)})
+			ids = append(ids, r.id)
+		}
+
+		reminders, err := s.pendingReminders(ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reminders) != len(ids) {
+			t.Fatalf("expected %d reminders, got %d", len(ids), len(reminders))
+		}
+		for i, r := range reminders {
+			if r.id != ids[len(ids)-1-i] {
+				t.Fatalf("expected the reminders ordered by time, got %d at %d", r.id, i)
+			}
+		}
+	})
+}
+
+func TestStorePreferences(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		// The defaults of the users without any preferences.
+		if timezone, err := s.timezonePreference("aurora"); err != nil || len(timezone) > 0 {
+			t.Errorf("expected no timezone, got %q and %v", timezone, err)
+		}
+		if preference, err := s.deliveryPreference("aurora"); err != nil || preference != deliverDefault {
+			t.Errorf("expected the default delivery, got %q and %v", preference, err)
+		}
+		if preference, err := s.clockPreference("aurora"); err != nil || preference != clock12h {
+			t.Errorf("expected the 12-hour clock, got %q and %v", preference, err)
+		}
+		if lang, err := s.language("aurora"); err != nil || lang != languageEnglish {
+			t.Errorf("expected English, got %q and %v", lang, err)
+		}
+		if preference, err := s.pronounPreference("aurora"); err != nil || preference != pronounsRewrite {
+			t.Errorf("expected the pronouns to be rewritten, got %q and %v", preference, err)
+		}
+		if preference, err := s.remindPreference("aurora"); err != nil || preference != remindByAnyone {
+			t.Errorf("expected anyone to remind, got %q and %v", preference, err)
+		}
+
+		// Setting them twice overwrites the first one, and they're kept per user.
+		for _, timezone := range []string{"Asia/Tokyo", "Europe/Warsaw"} {
+			if err := s.setTimezonePreference("aurora", timezone); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, preference := range []deliveryPreference{deliverHere, deliverDm} {
+			if err := s.setDeliveryPreference("aurora", preference); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, preference := range []clockPreference{clock12h, clock24h} {
+			if err := s.setClockPreference("aurora", preference); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, lang := range []language{languageEnglish, languagePolish} {
+			if err := s.setLanguage("aurora", lang); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, preference := range []pronounPreference{pronounsRewrite, pronounsKeep} {
+			if err := s.setPronounPreference("aurora", preference); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, preference := range []remindPreference{remindByAnyone, remindByMe} {
+			if err := s.setRemindPreference("aurora", preference); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if timezone, err := s.timezonePreference("aurora"); err != nil || timezone != "Europe/Warsaw" {
+			t.Errorf("expected Europe/Warsaw, got %q and %v", timezone, err)
+		}
+		if preference, err := s.deliveryPreference("aurora"); err != nil || preference != deliverDm {
+			t.Errorf("expected the DMs, got %q and %v", preference, err)
+		}
+		if preference, err := s.clockPreference("aurora"); err != nil || preference != clock24h {
+			t.Errorf("expected the 24-hour clock, got %q and %v", preference, err)
+		}
+		if lang, err := s.language("aurora"); err != nil || lang != languagePolish {
+			t.Errorf("expected Polish, got %q and %v", lang, err)
+		}
+		if preference, err := s.pronounPreference("aurora"); err != nil || preference != pronounsKeep {
+			t.Errorf("expected the pronouns to be kept, got %q and %v", preference, err)
+		}
+		if preference, err := s.remindPreference("aurora"); err != nil || preference != remindByMe {
+			t.Errorf("expected only the user to remind, got %q and %v", preference, err)
+		}
+		if timezone, err := s.timezonePreference("bruno"); err != nil || len(timezone) > 0 {
+			t.Errorf("expected no timezone for another user, got %q and %v", timezone, err)
+		}
+
+		// Going back to the defaults.
+		if err := s.setDeliveryPreference("aurora", deliverDefault); err != nil {
+			t.Fatal(err)
+		}
+		if preference, err := s.deliveryPreference("aurora"); err != nil || preference != deliverDefault {
+			t.Errorf("expected the default delivery, got %q and %v", preference, err)
+		}
+	})
+}
+
+func TestStoreGuildSettings(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ReminderStore) {
+		if settings, err := s.guildSettings("guild"); err != nil || !reflect.DeepEqual(settings, guildSettings{}) {
+			t.Errorf("expected the default settings, got %+v and %v", settings, err)
+		}
+
+		changes := [][2]string{
+			{"channel", "C1"}, {"timezone", "Asia/Tokyo"}, {"prefix", "?"}, {"roles", "1,2"}, {"pingroles", "3"},
+			{"channel", "C2"}, {"pingroles", ""},
+		}
+		for _, change := range changes {
+			if err := s.setGuildSetting("guild", change[0], change[1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.setGuildSetting("guild", "colour", "red"); err == nil {
+			t.Error("expected an unknown setting to be rejected")
+		}
+
+		expected := guildSettings{channelId: "C2", defaultTimezone: "Asia/Tokyo", prefix: "?", allowedRoles: []string{"1", "2"}}
+		if settings, err := s.guildSettings("guild"); err != nil || !reflect.DeepEqual(settings, expected) {
+			t.Errorf("expected %+v, got %+v and %v", expected, settings, err)
+		}
+		if settings, err := s.guildSettings("other"); err != nil || !reflect.DeepEqual(settings, guildSettings{}) {
+			t.Errorf("expected the other guild to keep the defaults, got %+v and %v", settings, err)
+		}
+	})
+}
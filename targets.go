@@ -0,0 +1,153 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+)
+
+// The target role of the reminders for everyone in the channel, mentioned as e.g. `@here` on Discord.
+const roleHere = "here"
+
+// Decides whether others can set reminders for the user with `!remind`. Set with `!remindpreference`.
+type remindPreference string
+
+const (
+	remindByAnyone remindPreference = "anyone"
+	// Only the user can set reminders for themselves.
+	remindByMe remindPreference = "me"
+)
+
+// Returns the user's preference, falling back to letting anyone remind them if it can't be read.
+func loadRemindPreference(who string) remindPreference {
+	preference, err := store.remindPreference(who)
+	if err != nil {
+		log.Println("Error querying the remind preference:", err)
+		return remindByAnyone
+	}
+
+	return preference
+}
+
+// The ways to point to everyone in the channel: the plain word, Discord's `@here`, Slack's `<!here>`
+// and Matrix's `@room`.
+var hereMentionRegex = regexp.MustCompile(`^(?:@?here|<!here(?:\|[^>]*)?>|@room)$`)
+
+// Handles `!remind <target> <time> <text>`, where the target is a user, a role or `here`. The reminders of a role
+// or everyone here belong to the author, while the ones for another user belong to that user, so that they can
+// remove them.
+func handleRemind(cmd *incomingCommand, input string) {
+	guildId, _ := cmd.origin()
+	if len(guildId) == 0 {
+		cmd.reply("You can only remind others on a server, you silly goose.")
+		return
+	}
+
+	mention, timeAndText, _ := strings.Cut(input, " ")
+
+	var who, role string
+	if hereMentionRegex.MatchString(mention) {
+		who, role = cmd.author, roleHere
+	} else {
+		id, isRole, ok := cmd.messenger.parseMention(mention)
+		if !ok {
+			cmd.reply(remindHelp)
+			return
+		}
+
+		who = id
+		if isRole {
+			who, role = cmd.author, id
+		}
+	}
+
+	// Reminding yourself is just `!remindme`.
+	if who == cmd.author && len(role) == 0 {
+		handleRemindme(cmd, timeAndText)
+		return
+	}
+
+	if len(role) > 0 && !loadGuildSettings(guildId).canPing(cmd.roles) && !cmd.messenger.canManageGuild(cmd) {
+		cmd.reply("Only the members with the roles set with `!config pingroles` and the server managers can remind roles or everyone here!")
+		return
+	}
+
+	if len(role) == 0 && loadRemindPreference(who) == remindByMe {
+		cmd.reply(cmd.sprintf("Sorry, %s doesn't want to be reminded by others.", cmd.messenger.mention(who)))
+		return
+	}
+
+	parsed, toRemind, errMsg, ok, matched := splitTimeExpression(cmd, timeAndText)
+	if !matched {
+		cmd.reply(remindHelp)
+		return
+	}
+
+	if len(toRemind) > 1500 {
+		cmd.reply("The maximum reminder length is 1500 characters, you naughty person.")
+		return
+	}
+
+	if !ok {
+		cmd.reply(errMsg)
+		return
+	}
+
+	// The pronouns stay as they are, "my" is still the author's and not the target's.
+	err := insertReminder(cmd, who, role, toRemind, parsed)
+	if err != nil {
+		log.Println("Error inserting into the database:", err)
+		cmd.reply("Something went wrong while inserting to the DB. Check the stderr output.")
+		return
+	}
+
+	cmd.reply(cmd.sprintf("Successfully added to the database. I'll remind %s %s %s.", mentionTarget(cmd.messenger, who, role), toRemind, parsed.description))
+}
+
+// Whether the user set the reminder for themselves alone.
+func (r reminder) isPersonal() bool {
+	return r.creator == r.who && len(r.targetRole) == 0
+}
+
+// Formats the mention of whoever the reminder is for.
+func mentionTarget(messenger Messenger, who string, role string) string {
+	if len(role) > 0 {
+		return messenger.mentionRole(role)
+	}
+
+	return messenger.mention(who)
+}
+
+// Formats the delivered reminder, which says who set it unless it's the reminded user. The lateness is only
+// mentioned when it's not empty.
+func formatReminder(messenger Messenger, r reminder, toRemind string, lateness string) string {
+	lang := loadLanguage(r.who)
+	target := mentionTarget(messenger, r.who, r.targetRole)
+	toRemind = messenger.escapeMentions(toRemind)
+
+	switch {
+	case r.isPersonal() && len(lateness) == 0:
+		return lang.sprintf("%s, reminding you %s.", target, toRemind)
+	case r.isPersonal():
+		return lang.sprintf("%s, reminding you %s (delivered %s late).", target, toRemind, lateness)
+	case len(lateness) == 0:
+		return lang.sprintf("%s, %s asked me to remind you %s.", target, messenger.mention(r.creator), toRemind)
+	default:
+		return lang.sprintf("%s, %s asked me to remind you %s (delivered %s late).", target, messenger.mention(r.creator), toRemind, lateness)
+	}
+}
+
+// Prefixes the reminder with whom it's for, or with who set it when it's for the viewer, e.g. "for @Aurora to buy
+// a gift". The viewer's own reminders are left as they are.
+func (lang language) describeTarget(messenger Messenger, r reminder, viewer string, toRemind string) string {
+	switch {
+	case len(r.targetRole) > 0:
+		return lang.sprintf("for %s %s", messenger.mentionRole(r.targetRole), toRemind)
+	case r.who != viewer:
+		return lang.sprintf("for %s %s", messenger.mention(r.who), toRemind)
+	case r.creator != viewer:
+		return lang.sprintf("from %s %s", messenger.mention(r.creator), toRemind)
+	default:
+		return toRemind
+	}
+}
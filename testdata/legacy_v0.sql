@@ -0,0 +1,9 @@
+-- A database whose bootstrap failed halfway. The first version of the bot created TimezonePreferences outside
+-- of the transaction, so it outlived the rollback while the version stayed at 0.
+CREATE TABLE IF NOT EXISTS TimezonePreferences (
+	id INTEGER NOT NULL PRIMARY KEY,
+	who TEXT NOT NULL,
+	timezonePreference TEXT NOT NULL
+);
+
+PRAGMA user_version = 0;
@@ -0,0 +1,18 @@
+-- A database bootstrapped by the first version of the bot, versioned with `PRAGMA user_version`.
+CREATE TABLE IF NOT EXISTS Reminders (
+	id INTEGER NOT NULL PRIMARY KEY,
+	who TEXT NOT NULL,
+	time DATETIME NOT NULL,
+	toRemind TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS TimezonePreferences (
+	id INTEGER NOT NULL PRIMARY KEY,
+	who TEXT NOT NULL,
+	timezonePreference TEXT NOT NULL
+);
+
+INSERT INTO Reminders(who, time, toRemind) VALUES('aurora', '2026-11-01 09:00:00+00:00', 'to buy milk');
+INSERT INTO TimezonePreferences(who, timezonePreference) VALUES('aurora', 'Europe/Warsaw');
+
+PRAGMA user_version = 1;
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One of the ways to say when a reminder fires, e.g. `in 2 days` or `tomorrow at 9`.
+type timeSyntax struct {
+	// Matches the time expression followed by the reminder text, which is the last group.
+	withText *regexp.Regexp
+	// Like withText, but without the `in Tokyo` timezone, for when the capitalized words after `in` aren't a city,
+	// e.g. `at 9 in Tesco buy milk`.
+	withoutCity *regexp.Regexp
+	// Matches just the time expression, e.g. for `!editreminder <ID> time`. As nothing follows it, the durations
+	// can be joined with bare spaces.
+	alone *regexp.Regexp
+	// Gets the matches of either of the regexes. On invalid input, returns the message for the user.
+	parse func(cmd *incomingCommand, matches []string) (reminderTime, string, bool)
+}
+
+var looseDurations = strings.NewReplacer(durationSyntax, looseDurationSyntax, polishDurationSyntax, polishLooseDurationSyntax)
+
+func newTimeSyntax(pattern string, parse func(cmd *incomingCommand, matches []string) (reminderTime, string, bool)) timeSyntax {
+	return timeSyntax{
+		withText:    regexp.MustCompile("^(?:" + pattern + ") (.+)$"),
+		withoutCity: regexp.MustCompile("^(?:" + strings.ReplaceAll(pattern, inCitySyntax, "") + ") (.+)$"),
+		alone:       regexp.MustCompile("^(?:" + looseDurations.Replace(pattern) + ")$"),
+		parse:       parse,
+	}
+}
+
+// The current time the expressions are parsed and the reminders snoozed against, fixed in the tests.
+var timeNow = time.Now
+
+// The optional timezone after the time expression, see timezoneNameSyntax.
+var timezoneSyntax = `(?: ` + timezoneNameSyntax + `)?`
+
+// The building blocks of the natural-language syntaxes.
+const (
+	// E.g. `9`, `17:45`, `9 AM`, `9:30pm`, `noon` or `midnight`. Without AM or PM, the hour is on the 24-hour clock.
+	clockSyntax   = `(noon|midnight|\d{1,2}(?::\d{2})?(?: ?[aApP][mM])?)`
+	weekdaySyntax = `(monday|tuesday|wednesday|thursday|friday|saturday|sunday)`
+	monthSyntax   = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+	ordinalSyntax = `(\d{1,2})(?:st|nd|rd|th)?`
+)
+
+// ISO 8601 / RFC 3339 date with an optional time and UTC offset, e.g. `2026-12-23T17:30` or `2026-12-23T17:30:00+01:00`.
+var isoTimeSyntax = `(?:at |on )?(\d{4})-(\d{2})-(\d{2})(?:[T ](\d{2}):(\d{2})(?::(\d{2})(?:\.\d+)?)?(Z|[+-]\d{2}(?::?\d{2})?)?)?` + timezoneSyntax
+
+// The building blocks of the Polish syntaxes, e.g. `jutro o 9` or `za 2 godziny`. They get translated into
+// the English ones before parsing.
+const (
+	// E.g. `o 9`, `o 17:45`, `o 9.30`, `w południe` or `o północy`. Poles use the 24-hour clock.
+	polishAtClockSyntax = `(?:o|w) (południe|północy?|\d{1,2}(?:[:.]\d{2})?)`
+	polishClockSyntax   = `(?: ` + polishAtClockSyntax + `)?`
+	polishWeekdaySyntax = `(poniedziałek|wtorek|środ[aę]|czwartek|piątek|sobot[aę]|niedziel[aę])`
+	polishMonthSyntax   = `(stycznia|lutego|marca|kwietnia|maja|czerwca|lipca|sierpnia|września|października|listopada|grudnia)`
+
+	polishDurationUnitSyntax   = `sekund[ęy]?|sek|minut[ęy]?|min|godzin[ęy]?|godz|dzień|dnia|dni|tydzień|tygodni[ae]?|tyg|miesiąca|miesiące|miesięcy|miesiąc|lata?|roku|rok|[dhms]`
+	polishDurationAmountSyntax = `\d+(?:[.,]\d+)? ?|półtor(?:a|ej) |pół `
+	// The amount can be left out for one unit, e.g. `za godzinę`.
+	polishDurationPartSyntax = `(` + polishDurationAmountSyntax + `)?(` + polishDurationUnitSyntax + `)`
+	// E.g. `2 godziny`, `godzinę i 30 minut`, `pół godziny` or `2h30m`, see durationSyntax.
+	polishDurationSyntax      = `(?:` + polishDurationAmountSyntax + `)?(?:` + polishDurationUnitSyntax + `)(?:(?:,? i |, )?(?:` + polishDurationAmountSyntax + `)(?:` + polishDurationUnitSyntax + `))*`
+	polishLooseDurationSyntax = `(?:` + polishDurationAmountSyntax + `)?(?:` + polishDurationUnitSyntax + `)(?:(?:,? i |, | )?(?:` + polishDurationAmountSyntax + `)(?:` + polishDurationUnitSyntax + `))*`
+)
+
+// The time syntaxes of `!remindme`, tried in order. The rigid ones come first, so that they keep
+// their meaning, e.g. `on 23.12 at 12 PM`.
+var timeSyntaxes = []timeSyntax{
+	newTimeSyntax(absoluteTimeSyntax, parseAbsoluteTime),
+	newTimeSyntax(isoTimeSyntax, parseIsoTime),
+	newTimeSyntax(relativeTimeSyntax, parseRelativeTime),
+	newTimeSyntax(recurringTimeSyntax, parseRecurringTime),
+	newTimeSyntax(cronTimeSyntax, parseCronTime),
+	newTimeSyntax(`(?i)(today|tonight|tomorrow|(?:the )?day after tomorrow)(?: at `+clockSyntax+`)?`+timezoneSyntax, parseDayTime),
+	newTimeSyntax(`(?i)(?:(next|this|on) )?`+weekdaySyntax+`(?: at `+clockSyntax+`)?`+timezoneSyntax, parseWeekdayTime),
+	newTimeSyntax(`(?i)(?:(this|next|on the) )?weekend(?: at `+clockSyntax+`)?`+timezoneSyntax, parseWeekendTime),
+	newTimeSyntax(`(?i)at `+clockSyntax+timezoneSyntax, parseClockTime),
+	newTimeSyntax(`(?i)on `+monthSyntax+` `+ordinalSyntax+`(?:,? (\d{4}))?(?: at `+clockSyntax+`)?`+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseMonthDayTime(cmd, matches[1], matches[2], matches[3], matches[4], matches[5])
+	}),
+	newTimeSyntax(`(?i)on `+ordinalSyntax+` `+monthSyntax+`(?: (\d{4}))?(?: at `+clockSyntax+`)?`+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseMonthDayTime(cmd, matches[2], matches[1], matches[3], matches[4], matches[5])
+	}),
+	newTimeSyntax(`(?i)end of (day|week|month)`+timezoneSyntax, parseEndOfTime),
+	newTimeSyntax(`(?i)za (`+polishDurationSyntax+`)`, parsePolishRelativeTime),
+	newTimeSyntax(`(?i)((?:dziś |dzisiaj )?wieczorem|dziś|dzisiaj|jutro|pojutrze)`+polishClockSyntax+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseDayTime(cmd, []string{matches[0], polishDay(matches[1]), polishClock(matches[2]), matches[3]})
+	}),
+	newTimeSyntax(`(?i)(?:(?:we?|na) )?(?:(przyszł[yąe]|następn[yąe]|najbliższ[yąe]|ten|tę) )?`+polishWeekdaySyntax+polishClockSyntax+timezoneSyntax, parsePolishWeekdayTime),
+	newTimeSyntax(`(?i)(?:w|na) (?:(ten|przyszły|następny|najbliższy) )?weekend`+polishClockSyntax+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseWeekendTime(cmd, []string{matches[0], polishNext(matches[1]), polishClock(matches[2]), matches[3]})
+	}),
+	newTimeSyntax(`(?i)`+polishAtClockSyntax+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseClockTime(cmd, []string{matches[0], polishClock(matches[1]), matches[2]})
+	}),
+	newTimeSyntax(`(?i)(\d{1,2}) `+polishMonthSyntax+`(?: (\d{4}))?`+polishClockSyntax+timezoneSyntax, func(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+		return parseMonthDayTime(cmd, polishMonthsByName[strings.ToLower(matches[2])].String(), matches[1], matches[3], polishClock(matches[4]), matches[5])
+	}),
+}
+
+// Parses the matches and makes sure the reminder doesn't fire later than maxHorizon from now.
+func (syntax timeSyntax) parseWithinHorizon(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	parsed, errMsg, ok := syntax.parse(cmd, matches)
+	if !ok {
+		return parsed, errMsg, false
+	}
+
+	limit, _, _ := addDuration(timeNow().UTC(), maxHorizon)
+	if parsed.targetTime.After(limit) {
+		return reminderTime{}, cmd.sprintf("I can't remember things for longer than %s, you'll have to set it closer to the date.", maxHorizon), false
+	}
+
+	return parsed, "", true
+}
+
+// Splits the `!remindme` arguments into the time expression and the reminder text, e.g. `tomorrow at 9 to call mom`.
+// Returns false as the last value if none of the syntaxes matched. On invalid input, returns the message for the user.
+func splitTimeExpression(cmd *incomingCommand, input string) (reminderTime, string, string, bool, bool) {
+	for _, syntax := range timeSyntaxes {
+		matches := syntax.withText.FindStringSubmatch(input)
+		if matches == nil {
+			continue
+		}
+
+		if hasUnknownCity(cmd, matches) {
+			if withoutCity := syntax.withoutCity.FindStringSubmatch(input); withoutCity != nil {
+				matches = withoutCity
+			}
+		}
+
+		parsed, errMsg, ok := syntax.parseWithinHorizon(cmd, matches)
+		return parsed, matches[len(matches)-1], errMsg, ok, true
+	}
+
+	return reminderTime{}, "", "", false, false
+}
+
+// Whether the matches contain the `in Tokyo` timezone with something that isn't a timezone, e.g. `in Tesco`.
+func hasUnknownCity(cmd *incomingCommand, matches []string) bool {
+	// The last match is the reminder text.
+	for _, match := range matches[1 : len(matches)-1] {
+		if inCityRegex.MatchString(match) {
+			_, _, ok := lookupTimezone(cmd, unmarkTimezone(match))
+			return !ok
+		}
+	}
+
+	return false
+}
+
+// Parses the time in any of the `!remindme` syntaxes, without the reminder text.
+// On invalid input, returns the message for the user.
+func parseTimeSyntax(cmd *incomingCommand, input string) (reminderTime, string, bool) {
+	for _, syntax := range timeSyntaxes {
+		if matches := syntax.alone.FindStringSubmatch(input); matches != nil {
+			return syntax.parseWithinHorizon(cmd, matches)
+		}
+	}
+
+	return reminderTime{}, "The time has to follow one of the `!remindme` syntaxes, e.g. `in 2 days`, `tomorrow at 9` or `every monday at 9 AM`.", false
+}
+
+// Used when the expression doesn't say the time of day, e.g. `tomorrow` or `on Dec 24`.
+const defaultHour = 9
+
+var clockRegex = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?: ?([aApP][mM]))?$`)
+
+// Parses the clockSyntax match into the 24-hour clock. Empty clock means the fallback hour.
+func parseClock(clock string, fallbackHour int) (int, int, string, bool) {
+	switch strings.ToLower(clock) {
+	case "":
+		return fallbackHour, 0, "", true
+	case "noon":
+		return 12, 0, "", true
+	case "midnight":
+		return 0, 0, "", true
+	}
+
+	clockMatches := clockRegex.FindStringSubmatch(clock)
+	hour, _ := strconv.Atoi(clockMatches[1])
+	minute, _ := strconv.Atoi(clockMatches[2])
+	period := strings.ToUpper(clockMatches[3])
+
+	if minute > 59 {
+		return 0, 0, "Are you sure you understand the clock?", false
+	}
+
+	if len(period) == 0 {
+		if hour > 23 {
+			return 0, 0, "There aren't that many hours in a day!", false
+		}
+		return hour, minute, "", true
+	}
+
+	if hour == 0 || hour > 12 {
+		return 0, 0, "The time has to follow the [12-hour clock](https://en.wikipedia.org/wiki/12-hour_clock) when you add AM or PM.", false
+	}
+
+	if period == "AM" && hour == 12 {
+		hour = 0
+	} else if period == "PM" && hour < 12 {
+		hour += 12
+	}
+
+	return hour, minute, "", true
+}
+
+// Describes the one-time reminder's time for the confirmation, on the author's clock. The weekday and the full date
+// are spelled out, so that the user can see which day e.g. `next friday` turned out to be.
+func describeLocalTime(cmd *incomingCommand, targetTime time.Time, location *time.Location) string {
+	localTime := targetTime.In(location)
+	return cmd.sprintf("on %s, %s at %s in the %s timezone", cmd.language().onWeekday(localTime.Weekday()), localTime.Format("02.01.2006"), loadClockPreference(cmd.author).format(localTime), location.String())
+}
+
+// Resolves the location and the clock of the natural-language syntaxes. On invalid input, returns the message for the user.
+func resolveLocationAndClock(cmd *incomingCommand, locationMatch string, clock string, fallbackHour int) (*time.Location, int, int, string, bool) {
+	location, errMsg, ok := resolveLocation(cmd, locationMatch)
+	if !ok {
+		return nil, 0, 0, errMsg, false
+	}
+
+	hour, minute, errMsg, ok := parseClock(clock, fallbackHour)
+	if !ok {
+		return nil, 0, 0, errMsg, false
+	}
+
+	return location, hour, minute, "", true
+}
+
+func oneTimeReminder(cmd *incomingCommand, targetTime time.Time, location *time.Location) reminderTime {
+	return reminderTime{
+		targetTime:  targetTime.UTC(),
+		location:    location,
+		description: describeLocalTime(cmd, targetTime, location),
+	}
+}
+
+// Parses the matches of isoTimeSyntax. Without the time, it's the defaultHour. The UTC offset, if any, takes
+// the place of the timezone.
+func parseIsoTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	offset := matches[7]
+	if len(offset) > 0 && len(matches[8]) > 0 {
+		return reminderTime{}, "Either the UTC offset or the timezone, not both, you silly goose.", false
+	}
+
+	var location *time.Location
+	if len(offset) > 0 {
+		location = time.UTC
+	} else {
+		var errMsg string
+		var ok bool
+		location, errMsg, ok = resolveLocation(cmd, matches[8])
+		if !ok {
+			return reminderTime{}, errMsg, false
+		}
+	}
+
+	// The offset is only needed to read the time, a fixed offset is the same as UTC for a one-time reminder.
+	readLocation := location
+	if len(offset) > 0 && offset != "Z" {
+		hours, _ := strconv.Atoi(offset[1:3])
+		minutes, _ := strconv.Atoi(strings.TrimPrefix(offset[3:], ":"))
+		if hours > 14 || minutes > 59 {
+			return reminderTime{}, "There's no such UTC offset, who would've guessed?", false
+		}
+
+		seconds := hours*3600 + minutes*60
+		if offset[0] == '-' {
+			seconds = -seconds
+		}
+		readLocation = time.FixedZone(fmt.Sprintf("UTC%c%02d:%02d", offset[0], hours, minutes), seconds)
+	}
+
+	year, _ := strconv.Atoi(matches[1])
+	month, _ := strconv.Atoi(matches[2])
+	day, _ := strconv.Atoi(matches[3])
+	hour, minute, second := defaultHour, 0, 0
+	if len(matches[4]) > 0 {
+		hour, _ = strconv.Atoi(matches[4])
+		minute, _ = strconv.Atoi(matches[5])
+		second, _ = strconv.Atoi(matches[6])
+	}
+
+	if errMsg, ok := isAbsoluteDateValid(cmd, day, month, year, hour, minute, "", year); !ok {
+		return reminderTime{}, errMsg, false
+	}
+	if second > 59 {
+		return reminderTime{}, "Are you sure you understand the clock?", false
+	}
+
+	targetTime := time.Date(year, time.Month(month), day, hour, minute, second, 0, readLocation)
+	if !targetTime.After(timeNow()) {
+		return reminderTime{}, "The date cannot be in the past, who would've guessed?", false
+	}
+
+	parsed := oneTimeReminder(cmd, targetTime, location)
+	if readLocation != location {
+		parsed.description = describeLocalTime(cmd, targetTime, readLocation)
+	}
+	return parsed, "", true
+}
+
+// Parses `today at 5 PM`, `tonight`, `tomorrow at 9` or `the day after tomorrow`.
+func parseDayTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	day := strings.ToLower(matches[1])
+
+	fallbackHour := defaultHour
+	if day == "tonight" {
+		fallbackHour = 20
+	} else if day == "today" && len(matches[2]) == 0 {
+		return reminderTime{}, "Today when? Add the time, e.g. `today at 5 PM`.", false
+	}
+
+	location, hour, minute, errMsg, ok := resolveLocationAndClock(cmd, matches[3], matches[2], fallbackHour)
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	currentTime := timeNow().In(location)
+	daysAhead := 0
+	if day == "tomorrow" {
+		daysAhead = 1
+	} else if strings.HasSuffix(day, "after tomorrow") {
+		daysAhead = 2
+	}
+
+	targetTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day()+daysAhead, hour, minute, 0, 0, location)
+	if !targetTime.After(currentTime) {
+		return reminderTime{}, "This time has already passed today, who would've guessed?", false
+	}
+
+	return oneTimeReminder(cmd, targetTime, location), "", true
+}
+
+// Parses `friday at noon`, `on monday`, `this sunday at 8 PM` or `next friday at 9`. Without `next`, it's the nearest
+// such day that's still ahead, possibly today. With `next`, it's never today.
+func parseWeekdayTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, hour, minute, errMsg, ok := resolveLocationAndClock(cmd, matches[4], matches[3], defaultHour)
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	weekday := weekdaysByName[strings.ToLower(matches[2])]
+	currentTime := timeNow().In(location)
+
+	daysAhead := (int(weekday) - int(currentTime.Weekday()) + 7) % 7
+	if daysAhead == 0 && strings.ToLower(matches[1]) == "next" {
+		daysAhead = 7
+	}
+
+	targetTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day()+daysAhead, hour, minute, 0, 0, location)
+	if !targetTime.After(currentTime) {
+		targetTime = targetTime.AddDate(0, 0, 7)
+	}
+
+	return oneTimeReminder(cmd, targetTime, location), "", true
+}
+
+// Parses `this weekend`, `on the weekend at 10` or `next weekend`, which is the Saturday, or the Sunday if the time
+// on Saturday has already passed. With `next`, it's never the weekend that's already going on.
+func parseWeekendTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, hour, minute, errMsg, ok := resolveLocationAndClock(cmd, matches[3], matches[2], defaultHour)
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	currentTime := timeNow().In(location)
+	daysAhead := (int(time.Saturday) - int(currentTime.Weekday()) + 7) % 7
+	if currentTime.Weekday() == time.Sunday {
+		// The weekend started yesterday.
+		daysAhead = -1
+	}
+	if strings.ToLower(matches[1]) == "next" && daysAhead <= 0 {
+		daysAhead += 7
+	}
+
+	for _, day := range []int{daysAhead, daysAhead + 1} {
+		targetTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day()+day, hour, minute, 0, 0, location)
+		if targetTime.After(currentTime) {
+			return oneTimeReminder(cmd, targetTime, location), "", true
+		}
+	}
+
+	return reminderTime{}, "This weekend is almost over, who would've guessed? Try `next weekend` instead.", false
+}
+
+// Parses `at 17:45` or `at 9 PM`, which is today, or tomorrow if the time has already passed.
+func parseClockTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, hour, minute, errMsg, ok := resolveLocationAndClock(cmd, matches[2], matches[1], defaultHour)
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	currentTime := timeNow().In(location)
+	targetTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), hour, minute, 0, 0, location)
+	if !targetTime.After(currentTime) {
+		targetTime = targetTime.AddDate(0, 0, 1)
+	}
+
+	return oneTimeReminder(cmd, targetTime, location), "", true
+}
+
+// Parses `on Dec 24`, `on 24th December 2026 at 6 PM` and the like. Without the year, it's the nearest such day
+// that's still ahead.
+func parseMonthDayTime(cmd *incomingCommand, monthMatch string, dayMatch string, yearMatch string, clock string, locationMatch string) (reminderTime, string, bool) {
+	location, hour, minute, errMsg, ok := resolveLocationAndClock(cmd, locationMatch, clock, defaultHour)
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	month := months[strings.ToLower(monthMatch)[:3]]
+	day, _ := strconv.Atoi(dayMatch)
+	currentTime := timeNow().In(location)
+
+	year := currentTime.Year()
+	if len(yearMatch) > 0 {
+		year, _ = strconv.Atoi(yearMatch)
+	}
+
+	targetTime := time.Date(year, month, day, hour, minute, 0, 0, location)
+	// Dates like the 31st of April roll over to the next month.
+	if day == 0 || targetTime.Day() != day {
+		return reminderTime{}, cmd.sprintf("There aren't %d days in %s.", day, cmd.language().monthName(month)), false
+	}
+
+	if !targetTime.After(currentTime) {
+		if len(yearMatch) > 0 {
+			return reminderTime{}, "The date cannot be in the past, who would've guessed?", false
+		}
+
+		targetTime = targetTime.AddDate(1, 0, 0)
+		// The 29th of February doesn't come every year.
+		if targetTime.Day() != day {
+			return reminderTime{}, "This date won't come again next year, add the year explicitly.", false
+		}
+	}
+
+	return oneTimeReminder(cmd, targetTime, location), "", true
+}
+
+// When the working day ends, for the `end of day|week|month` syntax.
+const endOfDayHour = 17
+
+// Parses `end of day` (5 PM), `end of week` (Friday, 5 PM) or `end of month` (the last day, 5 PM), taking the nearest
+// one that's still ahead.
+func parseEndOfTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	location, errMsg, ok := resolveLocation(cmd, matches[2])
+	if !ok {
+		return reminderTime{}, errMsg, false
+	}
+
+	currentTime := timeNow().In(location)
+	endOf := func(daysAhead int, monthsAhead int) time.Time {
+		if monthsAhead > 0 {
+			// Day 0 of the following month is the last day of this one.
+			return time.Date(currentTime.Year(), currentTime.Month()+time.Month(monthsAhead), 0, endOfDayHour, 0, 0, 0, location)
+		}
+		return time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day()+daysAhead, endOfDayHour, 0, 0, 0, location)
+	}
+
+	var targetTime time.Time
+	switch strings.ToLower(matches[1]) {
+	case "day":
+		targetTime = endOf(0, 0)
+		if !targetTime.After(currentTime) {
+			targetTime = endOf(1, 0)
+		}
+	case "week":
+		daysAhead := (int(time.Friday) - int(currentTime.Weekday()) + 7) % 7
+		targetTime = endOf(daysAhead, 0)
+		if !targetTime.After(currentTime) {
+			targetTime = endOf(daysAhead+7, 0)
+		}
+	case "month":
+		targetTime = endOf(0, 1)
+		if !targetTime.After(currentTime) {
+			targetTime = endOf(0, 2)
+		}
+	}
+
+	return oneTimeReminder(cmd, targetTime, location), "", true
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April, "may": time.May, "jun": time.June,
+	"jul": time.July, "aug": time.August, "sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var polishDurationPartRegex = regexp.MustCompile(`(?i)` + polishDurationPartSyntax)
+
+// Parses the matches of the Polish `za <duration>`, e.g. `za 2 godziny`, as if it was `in 2 hours`.
+func parsePolishRelativeTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	parts := []string{}
+	for _, part := range polishDurationPartRegex.FindAllStringSubmatch(matches[1], -1) {
+		amount := strings.TrimSpace(strings.ToLower(part[1]))
+		switch amount {
+		case "":
+			amount = "1"
+		case "pół":
+			amount = "0.5"
+		case "półtora", "półtorej":
+			amount = "1.5"
+		default:
+			amount = strings.Replace(amount, ",", ".", 1)
+		}
+
+		parts = append(parts, amount+" "+polishDurationUnit(strings.ToLower(part[2])))
+	}
+
+	parsed, errMsg, ok := parseRelativeTime(cmd, []string{matches[0], strings.Join(parts, " ")})
+	if ok {
+		parsed.description = cmd.sprintf("in %s", matches[1])
+	}
+	return parsed, errMsg, ok
+}
+
+// Translates the unit of polishDurationUnitSyntax into the English one understood by addDuration.
+func polishDurationUnit(unit string) string {
+	switch {
+	case strings.HasPrefix(unit, "mies"):
+		return "months"
+	case strings.HasPrefix(unit, "s"):
+		return "seconds"
+	case strings.HasPrefix(unit, "m"):
+		return "minutes"
+	case strings.HasPrefix(unit, "godz") || unit == "h":
+		return "hours"
+	case strings.HasPrefix(unit, "d"):
+		return "days"
+	case strings.HasPrefix(unit, "t"):
+		return "weeks"
+	default:
+		return "years"
+	}
+}
+
+// Translates the day of the Polish syntax for parseDayTime, e.g. `pojutrze` becomes `day after tomorrow`.
+func polishDay(day string) string {
+	day = strings.ToLower(day)
+	switch {
+	case strings.HasSuffix(day, "wieczorem"):
+		return "tonight"
+	case day == "jutro":
+		return "tomorrow"
+	case day == "pojutrze":
+		return "day after tomorrow"
+	default:
+		return "today"
+	}
+}
+
+// Translates the polishAtClockSyntax match into clockSyntax.
+func polishClock(clock string) string {
+	clock = strings.ToLower(clock)
+	switch {
+	case clock == "południe":
+		return "noon"
+	case strings.HasPrefix(clock, "północ"):
+		return "midnight"
+	default:
+		return strings.Replace(clock, ".", ":", 1)
+	}
+}
+
+// Translates `przyszły`, `następna` and the like into `next`. `ten` and `najbliższy` mean the nearest one.
+func polishNext(word string) string {
+	word = strings.ToLower(word)
+	if strings.HasPrefix(word, "przyszł") || strings.HasPrefix(word, "następn") {
+		return "next"
+	}
+
+	return ""
+}
+
+// Parses `w piątek o 12`, `we wtorek`, `w przyszłą środę o 9` and the like, see parseWeekdayTime.
+func parsePolishWeekdayTime(cmd *incomingCommand, matches []string) (reminderTime, string, bool) {
+	weekday := polishWeekdaysByName[strings.ToLower(matches[2])]
+	return parseWeekdayTime(cmd, []string{matches[0], polishNext(matches[1]), weekday.String(), polishClock(matches[3]), matches[4]})
+}
+
+var polishWeekdaysByName = map[string]time.Weekday{
+	"poniedziałek": time.Monday, "wtorek": time.Tuesday, "środa": time.Wednesday, "środę": time.Wednesday,
+	"czwartek": time.Thursday, "piątek": time.Friday, "sobota": time.Saturday, "sobotę": time.Saturday,
+	"niedziela": time.Sunday, "niedzielę": time.Sunday,
+}
+
+// The months as used in the dates, e.g. `24 grudnia`.
+var polishMonthsByName = map[string]time.Month{
+	"stycznia": time.January, "lutego": time.February, "marca": time.March, "kwietnia": time.April,
+	"maja": time.May, "czerwca": time.June, "lipca": time.July, "sierpnia": time.August,
+	"września": time.September, "października": time.October, "listopada": time.November, "grudnia": time.December,
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Parses the time expressions as if it was the given time.
+func useFixedClock(t *testing.T, at time.Time) {
+	t.Helper()
+
+	previous := timeNow
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = previous })
+}
+
+func TestSplitTimeExpression(t *testing.T) {
+	useMemoryStore(t)
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday, in the default timezone. The clocks go back on October 25.
+	useFixedClock(t, time.Date(2026, 10, 14, 12, 0, 0, 0, warsaw))
+
+	tests := []struct {
+		input string
+		text  string
+		// The time in Warsaw, in dstTimeLayout. Empty when the expression is invalid.
+		at     string
+		rule   string
+		errMsg string
+	}{
+		// on 23.12 at 12 PM
+		{input: "on 23.12 at 12 PM to buy a gift", text: "to buy a gift", at: "2026-12-23 12:00 CET"},
+		{input: "on 23.12.2027 at 17:30 Europe/London to call Tom", text: "to call Tom", at: "2027-12-23 18:30 CET"},
+		{input: "on 23.12 at 9 JST to call Tom", text: "to call Tom", at: "2026-12-23 01:00 CET"},
+		{input: "on 31.02 at 9 to feed the cat", text: "to feed the cat", errMsg: "There aren't 31 days in this month."},
+		{input: "on 1.1.2020 at 9 to feed the cat", text: "to feed the cat", errMsg: "The year has to be either 2026 or 2027."},
+		{input: "on 14.10 at 11 to feed the cat", text: "to feed the cat", errMsg: "The date cannot be in the past, who would've guessed?"},
+		{input: "on 23.12 at 13 PM to feed the cat", text: "to feed the cat", errMsg: "The time has to follow the [12-hour clock](https://en.wikipedia.org/wiki/12-hour_clock) when you add AM or PM."},
+
+		// ISO 8601
+		{input: "2026-12-23T17:30 to buy a gift", text: "to buy a gift", at: "2026-12-23 17:30 CET"},
+		{input: "on 2026-12-23 to buy a gift", text: "to buy a gift", at: "2026-12-23 09:00 CET"},
+		{input: "2026-12-23T17:30:00Z to buy a gift", text: "to buy a gift", at: "2026-12-23 18:30 CET"},
+		{input: "2026-12-23T17:30:00-05:00 to buy a gift", text: "to buy a gift", at: "2026-12-23 23:30 CET"},
+		{input: "2026-12-23T17:30+01:00 Europe/Warsaw to buy a gift", text: "to buy a gift", errMsg: "Either the UTC offset or the timezone, not both, you silly goose."},
+		{input: "2026-12-23T17:30+15:00 to buy a gift", text: "to buy a gift", errMsg: "There's no such UTC offset, who would've guessed?"},
+		{input: "2026-10-14T11:59 to buy a gift", text: "to buy a gift", errMsg: "The date cannot be in the past, who would've guessed?"},
+
+		// in 2 hours
+		{input: "in 2 hours to stretch", text: "to stretch", at: "2026-10-14 14:00 CEST"},
+		{input: "in 1h30m to stretch", text: "to stretch", at: "2026-10-14 13:30 CEST"},
+		{input: "in a week and 2 days to stretch", text: "to stretch", at: "2026-10-23 12:00 CEST"},
+		{input: "in 2 weeks to stretch", text: "to stretch", at: "2026-10-28 11:00 CET"},
+		// Only the commas, `and` and nothing at all join the parts, the words after a space are the text.
+		{input: "in 1 hour, 30 minutes to stretch", text: "to stretch", at: "2026-10-14 13:30 CEST"},
+		{input: "in 1 hour 30 minutes to stretch", text: "30 minutes to stretch", at: "2026-10-14 13:00 CEST"},
+		{input: "in 1 hour a week review", text: "a week review", at: "2026-10-14 13:00 CEST"},
+		{input: "in 2 hours a day off request", text: "a day off request", at: "2026-10-14 14:00 CEST"},
+		{input: "in 5 minutes 2 d batteries", text: "2 d batteries", at: "2026-10-14 12:05 CEST"},
+		{input: "in 0 seconds to stretch", text: "to stretch", errMsg: rightNowMessage},
+		{input: "in 1.5 months to stretch", text: "to stretch", errMsg: "A fraction of a month is hard to tell, use weeks or days instead."},
+		{input: "in 2000000 days to stretch", text: "to stretch", errMsg: "That's way too far in the future, who would've guessed?"},
+
+		// every day at 9
+		{input: "every day at 9 to water the plants", text: "to water the plants", at: "2026-10-15 09:00 CEST", rule: "1 day"},
+		// Without the time, at the current time of day from tomorrow on.
+		{input: "every 2 weeks to water the plants", text: "to water the plants", at: "2026-10-15 12:00 CEST", rule: "2 week"},
+		{input: "every monday at 9 AM to water the plants", text: "to water the plants", at: "2026-10-19 09:00 CEST", rule: "1 week monday"},
+		{input: "every weekday at 18:00 to water the plants", text: "to water the plants", at: "2026-10-14 18:00 CEST", rule: "1 day monday,tuesday,wednesday,thursday,friday"},
+		{input: "every day at 25 to water the plants", text: "to water the plants", errMsg: "There aren't that many hours in a day!"},
+
+		// cron "0 9 * * 1"
+		{input: `cron "0 9 * * 1" to plan the week`, text: "to plan the week", at: "2026-10-19 09:00 CEST", rule: "cron CRON_TZ=Europe/Warsaw 0 9 * * 1"},
+		{input: `cron "0 9 * * 1" UTC to plan the week`, text: "to plan the week", at: "2026-10-19 11:00 CEST", rule: "cron CRON_TZ=UTC 0 9 * * 1"},
+		{input: `cron "0 0 30 2 *" to plan the week`, text: "to plan the week", errMsg: "This cron expression never fires, who would've guessed?"},
+
+		// tomorrow at 9
+		{input: "tomorrow at 9 to call mom", text: "to call mom", at: "2026-10-15 09:00 CEST"},
+		{input: "Tomorrow to call mom", text: "to call mom", at: "2026-10-15 09:00 CEST"},
+		{input: "tonight to call mom", text: "to call mom", at: "2026-10-14 20:00 CEST"},
+		{input: "today at 5 PM to call mom", text: "to call mom", at: "2026-10-14 17:00 CEST"},
+		{input: "the day after tomorrow at noon to call mom", text: "to call mom", at: "2026-10-16 12:00 CEST"},
+		{input: "tomorrow at 9 America/New_York to call mom", text: "to call mom", at: "2026-10-15 15:00 CEST"},
+		{input: "today to call mom", text: "to call mom", errMsg: "Today when? Add the time, e.g. `today at 5 PM`."},
+		{input: "today at 9 to call mom", text: "to call mom", errMsg: "This time has already passed today, who would've guessed?"},
+		{input: "tomorrow at 9:75 to call mom", text: "to call mom", errMsg: "Are you sure you understand the clock?"},
+
+		// friday at noon
+		{input: "friday at noon to pay the rent", text: "to pay the rent", at: "2026-10-16 12:00 CEST"},
+		{input: "on wednesday at 17:00 to pay the rent", text: "to pay the rent", at: "2026-10-14 17:00 CEST"},
+		{input: "wednesday at 10 to pay the rent", text: "to pay the rent", at: "2026-10-21 10:00 CEST"},
+		{input: "next wednesday to pay the rent", text: "to pay the rent", at: "2026-10-21 09:00 CEST"},
+		{input: "this sunday at 8 PM to pay the rent", text: "to pay the rent", at: "2026-10-18 20:00 CEST"},
+
+		// this weekend
+		{input: "this weekend to clean up", text: "to clean up", at: "2026-10-17 09:00 CEST"},
+		{input: "next weekend at 10 to clean up", text: "to clean up", at: "2026-10-17 10:00 CEST"},
+		{input: "on the weekend at midnight to clean up", text: "to clean up", at: "2026-10-17 00:00 CEST"},
+
+		// at 17:45
+		{input: "at 17:45 to leave", text: "to leave", at: "2026-10-14 17:45 CEST"},
+		{input: "at 9 to leave", text: "to leave", at: "2026-10-15 09:00 CEST"},
+		{input: "at 9 PM CET to leave", text: "to leave", at: "2026-10-14 21:00 CEST"},
+		{input: "at 9 +02:00 to leave", text: "to leave", at: "2026-10-15 09:00 CEST"},
+		{input: "at 9 UTC+14:30 to leave", text: "to leave", errMsg: "Only the whole-hour UTC offsets work as timezones, so `UTC+14:30` doesn't. Try the city instead."},
+
+		// on Dec 24
+		{input: "on Dec 24 to buy a tree", text: "to buy a tree", at: "2026-12-24 09:00 CET"},
+		{input: "on 24th December 2027 at 6 PM to buy a tree", text: "to buy a tree", at: "2027-12-24 18:00 CET"},
+		{input: "on October 14th to buy a tree", text: "to buy a tree", at: "2027-10-14 09:00 CEST"},
+		{input: "on April 31st to buy a tree", text: "to buy a tree", errMsg: "There aren't 31 days in April."},
+		{input: "on Feb 29 to buy a tree", text: "to buy a tree", errMsg: "There aren't 29 days in February."},
+		{input: "on Oct 1, 2026 to buy a tree", text: "to buy a tree", errMsg: "The date cannot be in the past, who would've guessed?"},
+
+		// end of day
+		{input: "end of day to send the report", text: "to send the report", at: "2026-10-14 17:00 CEST"},
+		{input: "end of week to send the report", text: "to send the report", at: "2026-10-16 17:00 CEST"},
+		{input: "end of month to send the report", text: "to send the report", at: "2026-10-31 17:00 CET"},
+
+		// The Polish syntaxes.
+		{input: "za 2 godziny zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-14 14:00 CEST"},
+		{input: "za godzinę i 30 minut zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-14 13:30 CEST"},
+		{input: "za półtorej godziny zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-14 13:30 CEST"},
+		{input: "za 2 godziny pół dnia wolnego", text: "pół dnia wolnego", at: "2026-10-14 14:00 CEST"},
+		{input: "za 2h30m zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-14 14:30 CEST"},
+		{input: "jutro o 9 zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-15 09:00 CEST"},
+		{input: "dziś wieczorem zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-14 20:00 CEST"},
+		{input: "pojutrze w południe zadzwonić do mamy", text: "zadzwonić do mamy", at: "2026-10-16 12:00 CEST"},
+		{input: "dzisiaj zadzwonić do mamy", text: "zadzwonić do mamy", errMsg: "Today when? Add the time, e.g. `today at 5 PM`."},
+		{input: "w piątek o 12 zapłacić czynsz", text: "zapłacić czynsz", at: "2026-10-16 12:00 CEST"},
+		{input: "w przyszłą środę zapłacić czynsz", text: "zapłacić czynsz", at: "2026-10-21 09:00 CEST"},
+		{input: "w ten weekend posprzątać", text: "posprzątać", at: "2026-10-17 09:00 CEST"},
+		{input: "o 17.30 wyjść", text: "wyjść", at: "2026-10-14 17:30 CEST"},
+		{input: "o północy wyjść", text: "wyjść", at: "2026-10-15 00:00 CEST"},
+		{input: "24 grudnia kupić choinkę", text: "kupić choinkę", at: "2026-12-24 09:00 CET"},
+		{input: "31 listopada kupić choinkę", text: "kupić choinkę", errMsg: "There aren't 31 days in November."},
+
+		// The `in Tokyo` timezone, told apart from the places that aren't cities.
+		{input: "tomorrow at 9 in Tokyo to call Ken", text: "to call Ken", at: "2026-10-15 02:00 CEST"},
+		{input: "at 9 in New York to call Ken", text: "to call Ken", at: "2026-10-14 15:00 CEST"},
+		{input: "jutro o 9 in Tokyo zadzwonić do Kena", text: "zadzwonić do Kena", at: "2026-10-15 02:00 CEST"},
+		{input: "at 9 in Tesco buy milk", text: "in Tesco buy milk", at: "2026-10-15 09:00 CEST"},
+		{input: "tomorrow in Narnia to read the book", text: "in Narnia to read the book", at: "2026-10-15 09:00 CEST"},
+		{input: "in 2 hours in Tokyo to call Ken", text: "in Tokyo to call Ken", at: "2026-10-14 14:00 CEST"},
+		{input: "tomorrow at 9 Mars/Olympus to call Ken", text: "to call Ken", errMsg: "I don't know the `Mars/Olympus` timezone. Try the IANA identifier (e.g. `America/New_York`), the abbreviation (e.g. `CET`), the UTC offset (e.g. `+02:00`) or the city (e.g. `Tokyo`)."},
+
+		// Nothing fires later than maxHorizon from now.
+		{input: "in 10 years to renew the passport", text: "to renew the passport", at: "2036-10-14 12:00 CEST"},
+		{input: "in 10 years and 1 day to renew the passport", text: "to renew the passport", errMsg: "I can't remember things for longer than 10 years, you'll have to set it closer to the date."},
+		{input: "2036-10-15 to renew the passport", text: "to renew the passport", errMsg: "I can't remember things for longer than 10 years, you'll have to set it closer to the date."},
+		{input: "za 11 lat odnowić paszport", text: "odnowić paszport", errMsg: "I can't remember things for longer than 10 years, you'll have to set it closer to the date."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+			parsed, text, errMsg, ok, matched := splitTimeExpression(cmd, test.input)
+			if !matched {
+				t.Fatal("expected a time expression")
+			}
+			if text != test.text {
+				t.Errorf("expected the text %q, got %q", test.text, text)
+			}
+
+			if len(test.errMsg) > 0 {
+				if ok || errMsg != test.errMsg {
+					t.Errorf("expected the error %q, got %q", test.errMsg, errMsg)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("unexpected error %q", errMsg)
+			}
+			if at := parsed.targetTime.In(warsaw).Format(dstTimeLayout); at != test.at {
+				t.Errorf("expected %s, got %s", test.at, at)
+			}
+			if parsed.rule != test.rule {
+				t.Errorf("expected the rule %q, got %q", test.rule, parsed.rule)
+			}
+		})
+	}
+}
+
+// Without the text after it, the parts of the duration can be joined with bare spaces.
+func TestParseTimeSyntax(t *testing.T) {
+	useMemoryStore(t)
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useFixedClock(t, time.Date(2026, 10, 14, 12, 0, 0, 0, warsaw))
+
+	tests := []struct {
+		input string
+		at    string
+	}{
+		{"in 1 hour 30 minutes", "2026-10-14 13:30 CEST"},
+		{"in 2 weeks 3 days", "2026-10-31 11:00 CET"},
+		{"za 2 godziny 30 minut", "2026-10-14 14:30 CEST"},
+		{"tomorrow at 9", "2026-10-15 09:00 CEST"},
+	}
+	for _, test := range tests {
+		cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+		parsed, errMsg, ok := parseTimeSyntax(cmd, test.input)
+		if !ok {
+			t.Errorf("%s: unexpected error %q", test.input, errMsg)
+		} else if at := parsed.targetTime.In(warsaw).Format(dstTimeLayout); at != test.at {
+			t.Errorf("%s: expected %s, got %s", test.input, test.at, at)
+		}
+	}
+
+	cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+	if _, _, ok := parseTimeSyntax(cmd, "in 1 hour a week review"); ok {
+		t.Error("expected the text after the duration to be rejected")
+	}
+}
+
+func TestSplitTimeExpressionWithoutTime(t *testing.T) {
+	useMemoryStore(t)
+	useFixedClock(t, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+
+	for _, input := range []string{"to buy milk", "someday to buy milk", "in a while to buy milk", "tomorrow", "at noon"} {
+		cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+		if _, _, _, _, matched := splitTimeExpression(cmd, input); matched {
+			t.Errorf("expected %q not to match any syntax", input)
+		}
+	}
+}
+
+// The time preference of the user and the guild only apply when the expression doesn't name the timezone.
+func TestSplitTimeExpressionResolvesTheTimezone(t *testing.T) {
+	useMemoryStore(t)
+	useFixedClock(t, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+
+	if err := saveGuildSetting("guild", "timezone", "America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		author string
+		input  string
+		at     string
+	}{
+		{"aurora", "tomorrow at 9 to call mom", "2026-10-15 13:00 UTC"},
+		{"bruno", "tomorrow at 9 to call mom", "2026-10-15 00:00 UTC"},
+		{"bruno", "tomorrow at 9 UTC to call mom", "2026-10-15 09:00 UTC"},
+	}
+	if err := store.setTimezonePreference("bruno", "Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		cmd := &incomingCommand{messenger: newRecordingMessenger(), author: test.author, guildId: "guild", channelId: "channel"}
+		parsed, _, errMsg, ok, _ := splitTimeExpression(cmd, test.input)
+		if !ok {
+			t.Errorf("%s: unexpected error %q", test.input, errMsg)
+		} else if at := parsed.targetTime.Format(dstTimeLayout); at != test.at {
+			t.Errorf("%s for %s: expected %s, got %s", test.input, test.author, test.at, at)
+		}
+	}
+}
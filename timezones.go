@@ -0,0 +1,435 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+	"unicode/utf8"
+)
+
+// Matches the identifiers of the IANA Time Zone Database, e.g. `UTC`, `America/New_York`,
+// `America/Argentina/Buenos_Aires` or `Etc/GMT+2`.
+var timezoneNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_+-]*(?:/[a-zA-Z0-9_+-]+)*$`)
+
+// The files of the database that aren't real timezones.
+var notTimezoneNames = []string{"Factory", "posixrules", "localtime"}
+
+var (
+	timezoneNames []string
+	// The zones by the lowercase city names, see cityTimezones.
+	timezonesByCity   map[string]string
+	timezoneNamesOnce sync.Once
+)
+
+// Returns the sorted identifiers from the IANA Time Zone Database, looked up in the same directories
+// time.LoadLocation looks in, or in the ZONEINFO one, which can also be a zip file. The database embedded
+// with time/tzdata can't be listed, it only makes time.LoadLocation work on the hosts without the database.
+func listTimezoneNames() []string {
+	loadTimezoneNames()
+	return timezoneNames
+}
+
+// Returns the zones by the lowercase city names, with spaces instead of the underscores, e.g. `new york`.
+func cityTimezones() map[string]string {
+	loadTimezoneNames()
+	return timezonesByCity
+}
+
+// Lists the database and indexes it by the cities only once, since both are needed for every timezone looked up.
+func loadTimezoneNames() {
+	timezoneNamesOnce.Do(func() {
+		timezoneNames = findTimezoneNames()
+		if len(timezoneNames) == 0 {
+			log.Println("Couldn't find the IANA Time Zone Database, the timezone autocompletion won't work.")
+		}
+
+		timezonesByCity = make(map[string]string, len(timezoneCities))
+		for _, name := range timezoneNames {
+			area, city, ok := strings.Cut(name, "/")
+			if !ok || !slices.Contains(cityTimezoneAreas, area) {
+				continue
+			}
+
+			// E.g. `America/Argentina/Buenos_Aires`.
+			city = city[strings.LastIndex(city, "/")+1:]
+			city = strings.ToLower(strings.ReplaceAll(city, "_", " "))
+			if _, exists := timezonesByCity[city]; !exists {
+				timezonesByCity[city] = name
+			}
+		}
+		for city, name := range timezoneCities {
+			timezonesByCity[city] = name
+		}
+	})
+}
+
+func findTimezoneNames() []string {
+	sources := []string{
+		"/usr/share/zoneinfo/",
+		"/usr/share/lib/zoneinfo/",
+		"/usr/lib/locale/TZ/",
+		"/etc/zoneinfo/",
+	}
+	if zoneinfo := os.Getenv("ZONEINFO"); len(zoneinfo) > 0 {
+		sources = append([]string{zoneinfo}, sources...)
+	}
+
+	for _, source := range sources {
+		var (
+			names []string
+			err   error
+		)
+		if strings.HasSuffix(source, ".zip") {
+			names, err = timezoneNamesFromZip(source)
+		} else {
+			names, err = timezoneNamesFromDir(source)
+		}
+
+		if err != nil || len(names) == 0 {
+			continue
+		}
+
+		slices.Sort(names)
+		return names
+	}
+
+	return nil
+}
+
+func timezoneNamesFromDir(dir string) ([]string, error) {
+	names := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, _ := filepath.Rel(dir, path)
+		// The `posix` and `right` directories duplicate the whole database.
+		if entry.IsDir() && (name == "posix" || name == "right") {
+			return filepath.SkipDir
+		}
+
+		if entry.IsDir() || !timezoneNameRegex.MatchString(name) || slices.Contains(notTimezoneNames, name) || !isTzif(path) {
+			return nil
+		}
+
+		names = append(names, name)
+		return nil
+	})
+
+	return names, err
+}
+
+func timezoneNamesFromZip(path string) ([]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	names := []string{}
+	for _, file := range archive.File {
+		if timezoneNameRegex.MatchString(file.Name) && !slices.Contains(notTimezoneNames, file.Name) {
+			names = append(names, file.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// Checks the magic number of the file, since the database directories also contain e.g. `zone1970.tab`.
+func isTzif(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+
+	return string(magic) == "TZif"
+}
+
+// Returns at most limit identifiers containing the query, ignoring the case.
+func searchTimezoneNames(query string, limit int) []string {
+	query = strings.ToLower(query)
+
+	matches := []string{}
+	for _, name := range listTimezoneNames() {
+		if strings.Contains(strings.ToLower(name), query) {
+			matches = append(matches, name)
+			if len(matches) == limit {
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// The common abbreviations, mapped to the zones that follow the same rules. The summer ones map to the same zones
+// as the winter ones, since the zones switch on their own.
+var timezoneAbbreviations = map[string]string{
+	"UTC": "UTC", "GMT": "UTC", "UT": "UTC",
+	"BST": "Europe/London", "WET": "Europe/Lisbon", "WEST": "Europe/Lisbon",
+	"CET": "Europe/Berlin", "CEST": "Europe/Berlin", "EET": "Europe/Athens", "EEST": "Europe/Athens", "MSK": "Europe/Moscow",
+	"SAST": "Africa/Johannesburg", "CAT": "Africa/Maputo", "EAT": "Africa/Nairobi", "WAT": "Africa/Lagos",
+	"IST": "Asia/Kolkata", "PKT": "Asia/Karachi", "ICT": "Asia/Bangkok", "WIB": "Asia/Jakarta", "HKT": "Asia/Hong_Kong",
+	"SGT": "Asia/Singapore", "JST": "Asia/Tokyo", "KST": "Asia/Seoul",
+	"AWST": "Australia/Perth", "ACST": "Australia/Adelaide", "ACDT": "Australia/Adelaide",
+	"AEST": "Australia/Sydney", "AEDT": "Australia/Sydney", "NZST": "Pacific/Auckland", "NZDT": "Pacific/Auckland",
+	"HST": "Pacific/Honolulu", "AKST": "America/Anchorage", "AKDT": "America/Anchorage",
+	"PST": "America/Los_Angeles", "PDT": "America/Los_Angeles", "MST": "America/Denver", "MDT": "America/Denver",
+	"CST": "America/Chicago", "CDT": "America/Chicago", "EST": "America/New_York", "EDT": "America/New_York",
+	"AST": "America/Halifax", "ADT": "America/Halifax", "NST": "America/St_Johns", "NDT": "America/St_Johns",
+	"BRT": "America/Sao_Paulo", "ART": "America/Argentina/Buenos_Aires",
+}
+
+// The big cities that don't have their own zones, besides the ones named after them, e.g. `Tokyo`.
+var timezoneCities = map[string]string{
+	"san francisco": "America/Los_Angeles", "seattle": "America/Los_Angeles", "san diego": "America/Los_Angeles",
+	"las vegas": "America/Los_Angeles", "dallas": "America/Chicago", "houston": "America/Chicago", "austin": "America/Chicago",
+	"boston": "America/New_York", "washington": "America/New_York", "philadelphia": "America/New_York",
+	"miami": "America/New_York", "atlanta": "America/New_York", "montreal": "America/Toronto",
+	"rio de janeiro": "America/Sao_Paulo", "munich": "Europe/Berlin", "frankfurt": "Europe/Berlin", "hamburg": "Europe/Berlin",
+	"barcelona": "Europe/Madrid", "milan": "Europe/Rome", "geneva": "Europe/Zurich", "saint petersburg": "Europe/Moscow",
+	"warszawa": "Europe/Warsaw", "kraków": "Europe/Warsaw", "krakow": "Europe/Warsaw", "wrocław": "Europe/Warsaw",
+	"gdańsk": "Europe/Warsaw", "poznań": "Europe/Warsaw", "łódź": "Europe/Warsaw",
+	"beijing": "Asia/Shanghai", "mumbai": "Asia/Kolkata", "delhi": "Asia/Kolkata", "new delhi": "Asia/Kolkata",
+	"bangalore": "Asia/Kolkata", "osaka": "Asia/Tokyo",
+}
+
+// The areas of the database whose zones are named after cities. The others are kept for backward compatibility,
+// e.g. `US/Pacific`, or aren't places people live in, e.g. `Antarctica/Troll`.
+var cityTimezoneAreas = []string{"Africa", "America", "Asia", "Atlantic", "Australia", "Europe", "Indian", "Pacific"}
+
+// E.g. `+02:00`, `-5`, `UTC+2` or `GMT-03:30`.
+var utcOffsetRegex = regexp.MustCompile(`^(?i:UTC|GMT)? ?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// Matches the timezone after the time expressions: an IANA identifier, a UTC offset or an uppercase abbreviation.
+// Anything else lookupTimezone understands, e.g. a city, needs a marker, either `(Tokyo)` or `in Tokyo`, so that
+// the first word of the reminder isn't taken for it, e.g. `Wake` in `tomorrow Wake up Bob`.
+var timezoneNameSyntax = `([a-zA-Z]+/[a-zA-Z0-9_+/-]+|(?:UTC|GMT)?[+-]\d{1,2}(?::?\d{2})?|` + timezoneAbbreviationSyntax() + `|\([^()]+\)` + inCitySyntax + `)`
+
+// The `in Tokyo` marker, which the time syntaxes without it leave out, see timeSyntax.withoutCity.
+const inCitySyntax = `|in (?-i:\p{Lu}\p{L}*(?:[ _]\p{Lu}\p{L}*)*)`
+
+var inCityRegex = regexp.MustCompile(`^(?i:in) (\p{Lu}\p{L}*(?:[ _]\p{Lu}\p{L}*)*)$`)
+
+// Removes the marker around the timezone matched by timezoneNameSyntax, e.g. `(Tokyo)` or `in New York`.
+func unmarkTimezone(match string) string {
+	if inner, ok := strings.CutPrefix(match, "("); ok {
+		return strings.TrimSuffix(inner, ")")
+	}
+
+	if cityMatches := inCityRegex.FindStringSubmatch(match); cityMatches != nil {
+		return cityMatches[1]
+	}
+
+	return match
+}
+
+func timezoneAbbreviationSyntax() string {
+	abbreviations := make([]string, 0, len(timezoneAbbreviations))
+	for abbreviation := range timezoneAbbreviations {
+		abbreviations = append(abbreviations, abbreviation)
+	}
+	// The longer ones first, so that e.g. `CEST` isn't taken for `CET`.
+	slices.SortFunc(abbreviations, func(a string, b string) int { return len(b) - len(a) })
+
+	return `(?-i:` + strings.Join(abbreviations, "|") + `)`
+}
+
+// Resolves the timezone the way the users write it: an IANA identifier in any case (e.g. `america/new_york`),
+// an abbreviation (e.g. `CET` or `PST`), a UTC offset (e.g. `+02:00` or `UTC-5`) or a city (e.g. `Tokyo`).
+// On failure, returns the message for the user, suggesting the close matches if there are any.
+func lookupTimezone(cmd *incomingCommand, name string) (*time.Location, string, bool) {
+	name = strings.TrimSpace(name)
+	normalized := strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+
+	if zone, ok := timezoneAbbreviations[strings.ToUpper(name)]; ok {
+		location, err := time.LoadLocation(zone)
+		return location, "", err == nil
+	}
+
+	if offsetMatches := utcOffsetRegex.FindStringSubmatch(name); offsetMatches != nil {
+		return lookupUtcOffset(cmd, name, offsetMatches)
+	}
+
+	for _, candidate := range listTimezoneNames() {
+		if strings.ToLower(candidate) == normalized {
+			location, err := time.LoadLocation(candidate)
+			return location, "", err == nil
+		}
+	}
+
+	if zone, ok := cityTimezones()[strings.ReplaceAll(normalized, "_", " ")]; ok {
+		location, err := time.LoadLocation(zone)
+		return location, "", err == nil
+	}
+
+	// Without the database listed, rely on time.LoadLocation alone. `Local` isn't a real timezone.
+	if len(listTimezoneNames()) == 0 && timezoneNameRegex.MatchString(name) && name != "Local" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location, "", true
+		}
+	}
+
+	if suggestions := suggestTimezones(normalized, 3); len(suggestions) > 0 {
+		return nil, cmd.sprintf("I don't know the `%s` timezone. Did you mean %s?", name, formatSuggestions(suggestions)), false
+	}
+
+	return nil, cmd.sprintf("I don't know the `%s` timezone. Try the IANA identifier (e.g. `America/New_York`), the abbreviation (e.g. `CET`), the UTC offset (e.g. `+02:00`) or the city (e.g. `Tokyo`).", name), false
+}
+
+// The whole-hour offsets are the `Etc/GMT` zones, whose signs are inverted, e.g. `+02:00` is `Etc/GMT-2`. There aren't
+// any fixed zones for the other offsets, so the zones that currently have them are suggested instead.
+func lookupUtcOffset(cmd *incomingCommand, name string, offsetMatches []string) (*time.Location, string, bool) {
+	hours, _ := strconv.Atoi(offsetMatches[2])
+	minutes, _ := strconv.Atoi(offsetMatches[3])
+	if hours > 14 || minutes > 59 || offsetMatches[1] == "-" && hours > 12 {
+		return nil, "There's no such UTC offset, who would've guessed?", false
+	}
+
+	if minutes == 0 {
+		if hours == 0 {
+			return time.UTC, "", true
+		}
+
+		inverted := "-"
+		if offsetMatches[1] == "-" {
+			inverted = "+"
+		}
+		location, err := time.LoadLocation(fmt.Sprintf("Etc/GMT%s%d", inverted, hours))
+		return location, "", err == nil
+	}
+
+	seconds := hours*3600 + minutes*60
+	if offsetMatches[1] == "-" {
+		seconds = -seconds
+	}
+
+	// Both in the winter and in the summer, since the offset might be the DST one.
+	now := timeNow()
+	instants := []time.Time{now, time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(now.Year(), time.July, 1, 0, 0, 0, 0, time.UTC)}
+
+	suggestions := []string{}
+	for _, candidate := range listTimezoneNames() {
+		area, _, _ := strings.Cut(candidate, "/")
+		if !slices.Contains(cityTimezoneAreas, area) {
+			continue
+		}
+
+		location, err := time.LoadLocation(candidate)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(instants, func(instant time.Time) bool {
+			_, offset := instant.In(location).Zone()
+			return offset == seconds
+		}) {
+			suggestions = append(suggestions, candidate)
+			if len(suggestions) == 3 {
+				break
+			}
+		}
+	}
+
+	if len(suggestions) == 0 {
+		return nil, cmd.sprintf("Only the whole-hour UTC offsets work as timezones, so `%s` doesn't. Try the city instead.", name), false
+	}
+
+	return nil, cmd.sprintf("Only the whole-hour UTC offsets work as timezones, so `%s` doesn't. Use the timezone instead, e.g. %s.", name, formatSuggestions(suggestions)), false
+}
+
+// Returns at most limit names the user might have meant, the closest first.
+func suggestTimezones(normalized string, limit int) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	maxDistance := 1 + utf8.RuneCountInString(normalized)/4
+	best := map[string]int{}
+	consider := func(written string, name string) {
+		distance := editDistance(normalized, strings.ToLower(strings.ReplaceAll(written, " ", "_")))
+		if previous, ok := best[name]; distance <= maxDistance && (!ok || distance < previous) {
+			best[name] = distance
+		}
+	}
+
+	for _, name := range listTimezoneNames() {
+		consider(name, name)
+	}
+	for abbreviation := range timezoneAbbreviations {
+		consider(abbreviation, abbreviation)
+	}
+	for city, name := range cityTimezones() {
+		consider(city, name)
+	}
+
+	suggestions := make([]suggestion, 0, len(best))
+	for name, distance := range best {
+		suggestions = append(suggestions, suggestion{name, distance})
+	}
+	slices.SortFunc(suggestions, func(a suggestion, b suggestion) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	names := []string{}
+	for _, suggestion := range suggestions[:min(limit, len(suggestions))] {
+		names = append(names, suggestion.name)
+	}
+
+	return names
+}
+
+func formatSuggestions(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// The Levenshtein distance, i.e. how many letters have to be inserted, deleted or changed to turn a into b.
+func editDistance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// The cities and the suggestions come from the database on the host.
+func requireTimezoneDatabase(t *testing.T) {
+	t.Helper()
+
+	if len(listTimezoneNames()) == 0 {
+		t.Skip("the IANA Time Zone Database isn't installed")
+	}
+}
+
+func TestLookupTimezone(t *testing.T) {
+	useMemoryStore(t)
+	requireTimezoneDatabase(t)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Europe/Warsaw", "Europe/Warsaw"},
+		{"america/new_york", "America/New_York"},
+		{"America/Argentina/Buenos_Aires", "America/Argentina/Buenos_Aires"},
+		{"Etc/GMT+2", "Etc/GMT+2"},
+		{"UTC", "UTC"},
+
+		// Both the winter and the summer abbreviations map to the zone that switches between them.
+		{"CET", "Europe/Berlin"},
+		{"CEST", "Europe/Berlin"},
+		{"cest", "Europe/Berlin"},
+		{"PDT", "America/Los_Angeles"},
+		{"JST", "Asia/Tokyo"},
+
+		// The signs of the Etc/GMT zones are inverted.
+		{"+02:00", "Etc/GMT-2"},
+		{"+2", "Etc/GMT-2"},
+		{"UTC-5", "Etc/GMT+5"},
+		{"GMT-0300", "Etc/GMT+3"},
+		{"+14", "Etc/GMT-14"},
+		{"-12:00", "Etc/GMT+12"},
+		{"+00:00", "UTC"},
+
+		{"Tokyo", "Asia/Tokyo"},
+		{"New York", "America/New_York"},
+		{"buenos aires", "America/Argentina/Buenos_Aires"},
+		{"Kraków", "Europe/Warsaw"},
+		{"San Francisco", "America/Los_Angeles"},
+		{unmarkTimezone("in New York"), "America/New_York"},
+		{unmarkTimezone("(Tokyo)"), "Asia/Tokyo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+			location, errMsg, ok := lookupTimezone(cmd, test.name)
+			if !ok {
+				t.Fatalf("unexpected error %q", errMsg)
+			}
+			if location.String() != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, location)
+			}
+		})
+	}
+}
+
+func TestLookupTimezoneRejectsTheUnknownOnes(t *testing.T) {
+	useMemoryStore(t)
+	requireTimezoneDatabase(t)
+
+	tests := []struct {
+		name string
+		// The start of the reply and the suggestions it has to contain.
+		prefix   string
+		contains []string
+	}{
+		{name: "+15", prefix: "There's no such UTC offset, who would've guessed?"},
+		{name: "-13:00", prefix: "There's no such UTC offset, who would've guessed?"},
+		{name: "+02:60", prefix: "There's no such UTC offset, who would've guessed?"},
+		{name: "UTC+14:30", prefix: "Only the whole-hour UTC offsets work as timezones, so `UTC+14:30` doesn't. Try the city instead."},
+		{name: "+05:30", prefix: "Only the whole-hour UTC offsets work as timezones, so `+05:30` doesn't. Use the timezone instead, e.g. ", contains: []string{"`Asia/Colombo`"}},
+		{name: "-03:30", prefix: "Only the whole-hour UTC offsets work as timezones, so `-03:30` doesn't. Use the timezone instead, e.g. ", contains: []string{"`America/St_Johns`"}},
+		{name: "Europe/Warsw", prefix: "I don't know the `Europe/Warsw` timezone. Did you mean `Europe/Warsaw`"},
+		{name: "Tokio", prefix: "I don't know the `Tokio` timezone. Did you mean `Asia/Tokyo`"},
+		{name: "new yrok", prefix: "I don't know the `new yrok` timezone. Did you mean `America/New_York`"},
+		{name: "Narnia", prefix: "I don't know the `Narnia` timezone. Try the IANA identifier"},
+		// Go's own name for the host's timezone isn't one.
+		{name: "Local", prefix: "I don't know the `Local` timezone."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &incomingCommand{messenger: newRecordingMessenger(), author: "aurora"}
+			location, errMsg, ok := lookupTimezone(cmd, test.name)
+			if ok {
+				t.Fatalf("expected an error, got %s", location)
+			}
+			if !strings.HasPrefix(errMsg, test.prefix) {
+				t.Errorf("expected the reply to start with %q, got %q", test.prefix, errMsg)
+			}
+			for _, suggestion := range test.contains {
+				if !strings.Contains(errMsg, suggestion) {
+					t.Errorf("expected the reply to suggest %s, got %q", suggestion, errMsg)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestTimezones(t *testing.T) {
+	requireTimezoneDatabase(t)
+
+	tests := []struct {
+		normalized string
+		first      string
+	}{
+		{"europe/warsw", "Europe/Warsaw"},
+		{"amerika/new_york", "America/New_York"},
+		{"tokio", "Asia/Tokyo"},
+		{"cestt", "CEST"},
+		{"kolkatta", "Asia/Kolkata"},
+	}
+
+	for _, test := range tests {
+		suggestions := suggestTimezones(test.normalized, 3)
+		if len(suggestions) == 0 || len(suggestions) > 3 || suggestions[0] != test.first {
+			t.Errorf("expected up to 3 suggestions for %q starting with %s, got %q", test.normalized, test.first, suggestions)
+		}
+	}
+
+	if suggestions := suggestTimezones("narnia", 3); len(suggestions) > 0 {
+		t.Errorf("expected no suggestions for narnia, got %q", suggestions)
+	}
+}
+
+// The timezone after the time expression is matched as a whole, e.g. `CEST` isn't taken for `CET`.
+func TestTimezoneNameSyntax(t *testing.T) {
+	regex := regexp.MustCompile("^" + timezoneNameSyntax + " (.+)$")
+
+	tests := []struct {
+		input    string
+		timezone string
+	}{
+		{"CEST to call mom", "CEST"},
+		{"CET to call mom", "CET"},
+		{"America/Argentina/Buenos_Aires to call mom", "America/Argentina/Buenos_Aires"},
+		{"+05:30 to call mom", "+05:30"},
+		{"UTC-5 to call mom", "UTC-5"},
+		{"(Tokyo) to call mom", "(Tokyo)"},
+		{"in New York to call mom", "in New York"},
+	}
+
+	for _, test := range tests {
+		matches := regex.FindStringSubmatch(test.input)
+		if matches == nil || matches[1] != test.timezone {
+			t.Errorf("expected %q to match the %s timezone, got %q", test.input, test.timezone, matches)
+		}
+	}
+
+	for _, input := range []string{"Wake up Bob", "in a while to call mom", "cet to call mom"} {
+		if matches := regex.FindStringSubmatch(input); matches != nil {
+			t.Errorf("expected %q not to match a timezone, got %q", input, matches[1])
+		}
+	}
+}